@@ -0,0 +1,172 @@
+package gracefulshutdown
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"sync"
+	"time"
+)
+
+// AuditEntry records what triggered a shutdown, maintenance or resume action.
+type AuditEntry struct {
+	Time     time.Time `json:"time"`
+	PID      int       `json:"pid"`
+	Action   string    `json:"action"`
+	Source   string    `json:"source"`
+	Identity string    `json:"identity,omitempty"`
+	Signal   string    `json:"signal,omitempty"`
+	Reason   string    `json:"reason,omitempty"`
+	Stack    string    `json:"stack,omitempty"`
+}
+
+type auditLog struct {
+	mu       sync.Mutex
+	path     string
+	maxBytes int64
+	keep     int
+	file     *os.File
+	size     int64
+}
+
+// WithAuditLog appends an AuditEntry for every trigger to the file at path,
+// one JSON object per line, synced to stable storage before the action runs.
+// The audit log is written independently of the Logger. When the file would
+// exceed maxBytes it is rotated to path.1, keeping at most keep rotated files;
+// a maxBytes of zero disables rotation.
+func WithAuditLog(path string, maxBytes int64, keep int) Option {
+	return func(m *Manager) {
+		m.audit = &auditLog{path: path, maxBytes: maxBytes, keep: keep}
+	}
+}
+
+func (m *Manager) auditSignal(action string, s os.Signal) {
+	m.writeAudit(AuditEntry{Action: action, Source: "signal", Signal: s.String()})
+}
+
+// auditCaller records a programmatic trigger with the stack of its caller.
+func (m *Manager) auditCaller(action, reason string) {
+	if m.audit == nil {
+		return
+	}
+	m.writeAudit(AuditEntry{Action: action, Source: "programmatic", Reason: reason, Stack: string(debug.Stack())})
+}
+
+// TriggerFrom starts the shutdown sequence like Trigger, for triggers that
+// arrive from outside the process, such as an admin HTTP endpoint or a control
+// socket. The audit entry records source and the identity of the requester,
+// such as a remote address or an authenticated user, instead of a stack.
+func (m *Manager) TriggerFrom(source, identity, reason string) {
+	if reason == "" {
+		reason = source
+	}
+	m.writeAudit(AuditEntry{Action: "shutdown", Source: source, Identity: identity, Reason: reason})
+	m.trigger(shutdownTrigger{reason: reason})
+}
+
+// PeerIdentity returns the identity of the process at the other end of a unix
+// socket connection, such as a control socket, as "uid=<uid> pid=<pid>", for
+// use with TriggerFrom. The credentials are read from the kernel (SO_PEERCRED)
+// and are only available on Linux.
+func PeerIdentity(conn net.Conn) (string, error) {
+	uc, ok := conn.(*net.UnixConn)
+	if !ok {
+		return "", fmt.Errorf("gracefulshutdown: peer identity: %T is not a unix socket connection", conn)
+	}
+	raw, err := uc.SyscallConn()
+	if err != nil {
+		return "", fmt.Errorf("gracefulshutdown: peer identity: %w", err)
+	}
+	var uid, pid int
+	var cerr error
+	if err := raw.Control(func(fd uintptr) { uid, pid, cerr = peerCredentials(fd) }); err != nil {
+		return "", fmt.Errorf("gracefulshutdown: peer identity: %w", err)
+	}
+	if cerr != nil {
+		return "", fmt.Errorf("gracefulshutdown: peer identity: %w", cerr)
+	}
+	return fmt.Sprintf("uid=%d pid=%d", uid, pid), nil
+}
+
+func (m *Manager) writeAudit(e AuditEntry) {
+	if m.audit == nil {
+		return
+	}
+	e.Time = time.Now()
+	e.PID = os.Getpid()
+	if err := m.audit.append(e); err != nil {
+		m.logger.Error(fmt.Sprintf("error on write audit log: %s", err.Error()))
+	}
+}
+
+func (a *auditLog) append(e AuditEntry) error {
+	b, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	b = append(b, '\n')
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.file == nil {
+		if err := a.open(); err != nil {
+			return err
+		}
+	}
+	if a.maxBytes > 0 && a.size > 0 && a.size+int64(len(b)) > a.maxBytes {
+		if err := a.rotate(); err != nil {
+			return err
+		}
+	}
+	n, err := a.file.Write(b)
+	a.size += int64(n)
+	if err != nil {
+		return err
+	}
+	return a.file.Sync()
+}
+
+func (a *auditLog) open() error {
+	f, err := os.OpenFile(a.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o600)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	a.file, a.size = f, info.Size()
+	return nil
+}
+
+func (a *auditLog) rotate() error {
+	if err := a.file.Close(); err != nil {
+		return err
+	}
+	a.file = nil
+	if a.keep < 1 {
+		if err := os.Remove(a.path); err != nil {
+			return err
+		}
+		return a.open()
+	}
+	for i := a.keep - 1; i > 0; i-- {
+		err := os.Rename(fmt.Sprintf("%s.%d", a.path, i), fmt.Sprintf("%s.%d", a.path, i+1))
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+	}
+	if err := os.Rename(a.path, a.path+".1"); err != nil {
+		return err
+	}
+	if err := syncDir(filepath.Dir(a.path)); err != nil {
+		return err
+	}
+	return a.open()
+}
@@ -0,0 +1,8 @@
+//go:build !unix
+
+package gracefulshutdown
+
+// syncDir is a no-op where directories cannot be synced.
+func syncDir(string) error {
+	return nil
+}
@@ -0,0 +1,139 @@
+package gracefulshutdown
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func readAudit(t *testing.T, path string) []AuditEntry {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	var entries []AuditEntry
+	for s := bufio.NewScanner(f); s.Scan(); {
+		var e AuditEntry
+		if err := json.Unmarshal(s.Bytes(), &e); err != nil {
+			t.Fatalf("invalid audit line %q: %v", s.Text(), err)
+		}
+		entries = append(entries, e)
+	}
+	return entries
+}
+
+func TestAuditTrigger(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	shutdown(t, NewManager(&testLogger{}, WithAuditLog(path, 0, 0)))
+
+	entries := readAudit(t, path)
+	if len(entries) != 1 {
+		t.Fatalf("entries = %+v", entries)
+	}
+	e := entries[0]
+	if e.Action != "shutdown" || e.Source != "programmatic" || e.Reason != "test" || e.PID != os.Getpid() {
+		t.Errorf("entry = %+v", e)
+	}
+	if !strings.Contains(e.Stack, "TestAuditTrigger") {
+		t.Errorf("stack does not name the caller:\n%s", e.Stack)
+	}
+}
+
+func TestAuditTriggerFrom(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	m := NewManager(&testLogger{}, WithAuditLog(path, 0, 0))
+	m.TriggerFrom("http", "10.0.0.7:51234", "")
+	<-handle(m)
+
+	entries := readAudit(t, path)
+	if len(entries) != 1 {
+		t.Fatalf("entries = %+v", entries)
+	}
+	e := entries[0]
+	if e.Source != "http" || e.Identity != "10.0.0.7:51234" || e.Reason != "http" || e.Stack != "" {
+		t.Errorf("entry = %+v", e)
+	}
+	if m.Report().Reason != "http" {
+		t.Errorf("report reason = %q", m.Report().Reason)
+	}
+}
+
+func TestAuditRotation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	m := NewManager(&testLogger{}, WithAuditLog(path, 100, 2))
+	for i := range 5 {
+		m.writeAudit(AuditEntry{Action: "shutdown", Source: "test", Reason: fmt.Sprint(i)})
+	}
+
+	var reasons []string
+	for _, p := range []string{path + ".2", path + ".1", path} {
+		for _, e := range readAudit(t, p) {
+			reasons = append(reasons, e.Reason)
+		}
+	}
+	if got := strings.Join(reasons, ","); got != "2,3,4" {
+		t.Errorf("kept entries %s", got)
+	}
+	if _, err := os.Stat(path + ".3"); !os.IsNotExist(err) {
+		t.Errorf("more than keep rotated files: %v", err)
+	}
+}
+
+func TestAuditRotationErrorIsLogged(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "audit.log")
+	logger := &testLogger{}
+	m := NewManager(logger, WithAuditLog(path, 100, 2))
+	m.writeAudit(AuditEntry{Action: "shutdown", Source: "test"})
+	// A directory in place of a rotated file makes the rename fail.
+	if err := os.MkdirAll(filepath.Join(path+".2", "busy"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path+".1", nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	m.writeAudit(AuditEntry{Action: "shutdown", Source: "test", Reason: strings.Repeat("x", 100)})
+	if !logger.contains("ERROR error on write audit log") {
+		t.Errorf("rotation error not logged:\n%s", logger)
+	}
+}
+
+func TestPeerIdentity(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("peer credentials are only available on Linux")
+	}
+	ln, err := net.Listen("unix", filepath.Join(t.TempDir(), "control.sock"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	client, err := net.Dial("unix", ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	server, err := ln.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer server.Close()
+
+	id, err := PeerIdentity(server)
+	if want := fmt.Sprintf("uid=%d pid=%d", os.Getuid(), os.Getpid()); err != nil || id != want {
+		t.Errorf("PeerIdentity = %q, %v, want %q", id, err, want)
+	}
+	a, b := net.Pipe()
+	defer a.Close()
+	defer b.Close()
+	if _, err := PeerIdentity(a); err == nil {
+		t.Error("identity read from a pipe")
+	}
+}
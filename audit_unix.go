@@ -0,0 +1,19 @@
+//go:build unix
+
+package gracefulshutdown
+
+import "os"
+
+// syncDir flushes the directory entry changes made by a rotation to stable
+// storage.
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	err = d.Sync()
+	if cerr := d.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
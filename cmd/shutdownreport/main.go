@@ -0,0 +1,243 @@
+// Command shutdownreport aggregates JSON shutdown reports written by
+// gracefulshutdown.WithReportFile across a fleet of instances.
+//
+// Usage:
+//
+//	shutdownreport [-format table|json] [-top n] path...
+//
+// Each path is a report file or a directory searched recursively for *.json
+// files. Files that cannot be read or decoded are skipped with a warning on
+// standard error and counted in the summary.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	gracefulshutdown "github.com/eviccari/graceful-shutdown"
+)
+
+type resourceStats struct {
+	Name     string        `json:"name"`
+	Phase    string        `json:"phase"`
+	Count    int           `json:"count"`
+	P50      time.Duration `json:"p50"`
+	P90      time.Duration `json:"p90"`
+	P99      time.Duration `json:"p99"`
+	Max      time.Duration `json:"max"`
+	Failures int           `json:"failures"`
+	Timeouts int           `json:"timeouts"`
+}
+
+type counted struct {
+	Value string `json:"value"`
+	Count int    `json:"count"`
+}
+
+type summary struct {
+	Reports             int             `json:"reports"`
+	Skipped             int             `json:"skipped"`
+	FailedReports       int             `json:"failed_reports"`
+	ResourceCalls       int             `json:"resource_calls"`
+	Timeouts            int             `json:"timeouts"`
+	TimeoutRate         float64         `json:"timeout_rate"`
+	SlowestResources    []resourceStats `json:"slowest_resources"`
+	CloseErrors         []counted       `json:"close_errors"`
+	Reasons             []counted       `json:"reasons"`
+	ExitCodes           []counted       `json:"exit_codes"`
+	ShutdownDurationP50 time.Duration   `json:"shutdown_duration_p50"`
+	ShutdownDurationP99 time.Duration   `json:"shutdown_duration_p99"`
+}
+
+func main() {
+	format := flag.String("format", "table", "output format: table or json")
+	top := flag.Int("top", 10, "number of entries in the slowest resources and close errors lists")
+	flag.Parse()
+	if flag.NArg() == 0 {
+		fmt.Fprintln(os.Stderr, "usage: shutdownreport [-format table|json] [-top n] path...")
+		os.Exit(2)
+	}
+
+	reports, skipped, err := load(flag.Args(), os.Stderr)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	s := aggregate(reports, *top)
+	s.Skipped = skipped
+	switch *format {
+	case "json":
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(s)
+	case "table":
+		err = writeTable(os.Stdout, s)
+	default:
+		err = fmt.Errorf("unknown format %q", *format)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// load reads the reports found in paths, warning on warn about the files that
+// cannot be read or decoded and returning how many were skipped. Only an
+// error walking the paths is returned.
+func load(paths []string, warn io.Writer) ([]gracefulshutdown.Report, int, error) {
+	var reports []gracefulshutdown.Report
+	skipped := 0
+	for _, root := range paths {
+		err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() || (path != root && filepath.Ext(path) != ".json") {
+				return nil
+			}
+			r, err := gracefulshutdown.ReadReport(path)
+			if err != nil {
+				fmt.Fprintf(warn, "skipping %s: %v\n", path, err)
+				skipped++
+				return nil
+			}
+			reports = append(reports, r)
+			return nil
+		})
+		if err != nil {
+			return nil, 0, err
+		}
+	}
+	return reports, skipped, nil
+}
+
+func aggregate(reports []gracefulshutdown.Report, top int) summary {
+	type key struct{ name, phase string }
+	durations := map[key][]time.Duration{}
+	stats := map[key]*resourceStats{}
+	closeErrors := map[string]int{}
+	reasons := map[string]int{}
+	exitCodes := map[string]int{}
+	var totals []time.Duration
+
+	s := summary{Reports: len(reports)}
+	for _, r := range reports {
+		if r.Failed() {
+			s.FailedReports++
+		}
+		reasons[r.Reason]++
+		exitCodes[strconv.Itoa(r.ExitCode)]++
+		totals = append(totals, r.Duration)
+		for _, res := range r.Resources {
+			k := key{res.Name, string(res.Phase)}
+			st := stats[k]
+			if st == nil {
+				st = &resourceStats{Name: res.Name, Phase: string(res.Phase)}
+				stats[k] = st
+			}
+			st.Count++
+			durations[k] = append(durations[k], res.Duration)
+			s.ResourceCalls++
+			if res.Error != "" {
+				st.Failures++
+				if res.Phase == gracefulshutdown.PhaseClose {
+					closeErrors[res.Error]++
+				}
+			}
+			if res.TimedOut {
+				st.Timeouts++
+				s.Timeouts++
+			}
+		}
+	}
+	if s.ResourceCalls > 0 {
+		s.TimeoutRate = float64(s.Timeouts) / float64(s.ResourceCalls)
+	}
+	for k, st := range stats {
+		d := durations[k]
+		sort.Slice(d, func(i, j int) bool { return d[i] < d[j] })
+		st.P50, st.P90, st.P99, st.Max = percentile(d, 50), percentile(d, 90), percentile(d, 99), d[len(d)-1]
+		s.SlowestResources = append(s.SlowestResources, *st)
+	}
+	sort.Slice(s.SlowestResources, func(i, j int) bool {
+		a, b := s.SlowestResources[i], s.SlowestResources[j]
+		if a.P99 != b.P99 {
+			return a.P99 > b.P99
+		}
+		return a.Name < b.Name
+	})
+	if len(s.SlowestResources) > top {
+		s.SlowestResources = s.SlowestResources[:top]
+	}
+	sort.Slice(totals, func(i, j int) bool { return totals[i] < totals[j] })
+	s.ShutdownDurationP50, s.ShutdownDurationP99 = percentile(totals, 50), percentile(totals, 99)
+	s.CloseErrors = rank(closeErrors, top)
+	s.Reasons = rank(reasons, 0)
+	s.ExitCodes = rank(exitCodes, 0)
+	return s
+}
+
+// percentile returns the nearest-rank percentile p of the sorted durations d.
+func percentile(d []time.Duration, p int) time.Duration {
+	if len(d) == 0 {
+		return 0
+	}
+	i := (len(d)*p + 99) / 100
+	if i < 1 {
+		i = 1
+	}
+	return d[i-1]
+}
+
+// rank sorts counts in descending order, keeping the first limit entries when
+// limit is positive.
+func rank(counts map[string]int, limit int) []counted {
+	out := make([]counted, 0, len(counts))
+	for v, n := range counts {
+		out = append(out, counted{Value: v, Count: n})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Count != out[j].Count {
+			return out[i].Count > out[j].Count
+		}
+		return out[i].Value < out[j].Value
+	})
+	if limit > 0 && len(out) > limit {
+		out = out[:limit]
+	}
+	return out
+}
+
+func writeTable(out io.Writer, s summary) error {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "reports\t%d\n", s.Reports)
+	fmt.Fprintf(w, "skipped files\t%d\n", s.Skipped)
+	fmt.Fprintf(w, "failed reports\t%d\n", s.FailedReports)
+	fmt.Fprintf(w, "shutdown duration p50/p99\t%v / %v\n", s.ShutdownDurationP50, s.ShutdownDurationP99)
+	fmt.Fprintf(w, "timeouts\t%d of %d calls (%.2f%%)\n", s.Timeouts, s.ResourceCalls, s.TimeoutRate*100)
+
+	fmt.Fprintln(w, "\nSLOWEST RESOURCES\nNAME\tPHASE\tCOUNT\tP50\tP90\tP99\tMAX\tFAILURES\tTIMEOUTS")
+	for _, r := range s.SlowestResources {
+		fmt.Fprintf(w, "%s\t%s\t%d\t%v\t%v\t%v\t%v\t%d\t%d\n", r.Name, r.Phase, r.Count, r.P50, r.P90, r.P99, r.Max, r.Failures, r.Timeouts)
+	}
+	writeCounts(w, "CLOSE ERRORS", "ERROR", s.CloseErrors)
+	writeCounts(w, "REASONS", "REASON", s.Reasons)
+	writeCounts(w, "EXIT CODES", "CODE", s.ExitCodes)
+	return w.Flush()
+}
+
+func writeCounts(w io.Writer, title, column string, counts []counted) {
+	fmt.Fprintf(w, "\n%s\n%s\tCOUNT\n", title, column)
+	for _, c := range counts {
+		fmt.Fprintf(w, "%s\t%d\n", c.Value, c.Count)
+	}
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	gracefulshutdown "github.com/eviccari/graceful-shutdown"
+)
+
+func TestPercentile(t *testing.T) {
+	d := []time.Duration{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}
+	for p, want := range map[int]time.Duration{50: 5, 90: 9, 99: 10, 100: 10, 0: 1} {
+		if got := percentile(d, p); got != want {
+			t.Errorf("p%d = %v, want %v", p, got, want)
+		}
+	}
+	if got := percentile(nil, 50); got != 0 {
+		t.Errorf("empty p50 = %v", got)
+	}
+}
+
+func TestRank(t *testing.T) {
+	got := rank(map[string]int{"b": 2, "a": 2, "c": 5, "d": 1}, 3)
+	want := []counted{{"c", 5}, {"a", 2}, {"b", 2}}
+	if len(got) != len(want) {
+		t.Fatalf("rank = %v", got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("rank[%d] = %v, want %v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestAggregate(t *testing.T) {
+	db := func(d time.Duration, err string, timedOut bool) gracefulshutdown.ResourceReport {
+		return gracefulshutdown.ResourceReport{Name: "db", Phase: gracefulshutdown.PhaseClose, Duration: d, Error: err, TimedOut: timedOut}
+	}
+	reports := []gracefulshutdown.Report{
+		{Reason: "signal: terminated", Duration: time.Second, Resources: []gracefulshutdown.ResourceReport{db(10, "", false)}},
+		{Reason: "signal: terminated", Duration: 3 * time.Second, ExitCode: gracefulshutdown.ExitFailed, Resources: []gracefulshutdown.ResourceReport{db(30, "context deadline exceeded", true)}},
+		{Reason: "preemption", Duration: 2 * time.Second, ExitCode: gracefulshutdown.ExitFailed, Resources: []gracefulshutdown.ResourceReport{db(20, "context deadline exceeded", true)}},
+	}
+	s := aggregate(reports, 10)
+
+	if s.Reports != 3 || s.FailedReports != 2 || s.Timeouts != 2 || s.ResourceCalls != 3 {
+		t.Errorf("counts = %+v", s)
+	}
+	if len(s.SlowestResources) != 1 || s.SlowestResources[0].P50 != 20 || s.SlowestResources[0].Max != 30 {
+		t.Errorf("slowest = %+v", s.SlowestResources)
+	}
+	if len(s.CloseErrors) != 1 || s.CloseErrors[0].Count != 2 {
+		t.Errorf("close errors = %+v", s.CloseErrors)
+	}
+	if len(s.ExitCodes) != 2 || s.ExitCodes[0] != (counted{"1", 2}) {
+		t.Errorf("exit codes = %+v", s.ExitCodes)
+	}
+	if s.Reasons[0] != (counted{"signal: terminated", 2}) {
+		t.Errorf("reasons = %+v", s.Reasons)
+	}
+	if s.ShutdownDurationP50 != 2*time.Second || s.ShutdownDurationP99 != 3*time.Second {
+		t.Errorf("durations = %v / %v", s.ShutdownDurationP50, s.ShutdownDurationP99)
+	}
+
+	var buf bytes.Buffer
+	if err := writeTable(&buf, s); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "context deadline exceeded  2") {
+		t.Errorf("table:\n%s", buf.String())
+	}
+}
+
+func TestLoadWalksDirectories(t *testing.T) {
+	dir := t.TempDir()
+	b, _ := json.Marshal(gracefulshutdown.Report{Reason: "test"})
+	files := map[string][]byte{
+		"a.json":         b,
+		"nested/b.json":  b,
+		"notes.txt":      b,
+		"truncated.json": b[:len(b)/2],
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		os.MkdirAll(filepath.Dir(path), 0o755)
+		if err := os.WriteFile(path, content, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var warnings bytes.Buffer
+	reports, skipped, err := load([]string{dir}, &warnings)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reports) != 2 || skipped != 1 {
+		t.Errorf("loaded %d reports and skipped %d, want 2 and 1", len(reports), skipped)
+	}
+	if !strings.Contains(warnings.String(), "skipping "+filepath.Join(dir, "truncated.json")) {
+		t.Errorf("warnings = %q", warnings.String())
+	}
+	if _, _, err := load([]string{filepath.Join(dir, "missing")}, &warnings); err == nil {
+		t.Error("missing path loaded without error")
+	}
+}
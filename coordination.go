@@ -0,0 +1,225 @@
+package gracefulshutdown
+
+import (
+	"bufio"
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Shutdown progress states exchanged between a Participant and a Coordinator.
+// Each message is a single line "<state> <name>" acknowledged with "ok".
+const (
+	PeerDraining = "draining"
+	PeerReady    = "ready"
+	PeerDone     = "done"
+)
+
+// Coordinator listens on a unix socket shared by sibling processes, such as
+// containers of the same pod, which report their shutdown progress with a
+// Participant. Handled by a Manager, its close blocks until every expected
+// peer has reported done, or until its timeout or the context deadline
+// passes, so that a shared resource outlives the processes that use it.
+type Coordinator struct {
+	path     string
+	timeout  time.Duration
+	listener net.Listener
+
+	mu       sync.Mutex
+	expected map[string]bool
+	states   map[string]string
+	allDone  chan struct{}
+}
+
+// NewCoordinator listens on the unix socket at path, replacing a stale
+// socket file, and waits for the named peers. A timeout of zero leaves the
+// wait bounded by the context only.
+func NewCoordinator(path string, timeout time.Duration, peers ...string) (*Coordinator, error) {
+	if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("gracefulshutdown: coordinator: %w", err)
+	}
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, fmt.Errorf("gracefulshutdown: coordinator: %w", err)
+	}
+	c := &Coordinator{
+		path:     path,
+		timeout:  timeout,
+		listener: ln,
+		expected: map[string]bool{},
+		states:   map[string]string{},
+		allDone:  make(chan struct{}),
+	}
+	for _, p := range peers {
+		c.expected[p] = true
+	}
+	c.checkDone()
+	go c.serve()
+	return c, nil
+}
+
+func (c *Coordinator) serve() {
+	for {
+		conn, err := c.listener.Accept()
+		if err != nil {
+			return
+		}
+		go c.handle(conn)
+	}
+}
+
+func (c *Coordinator) handle(conn net.Conn) {
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+	scanner := bufio.NewScanner(conn)
+	for scanner.Scan() {
+		state, name, ok := strings.Cut(strings.TrimSpace(scanner.Text()), " ")
+		if !ok || name == "" || (state != PeerDraining && state != PeerReady && state != PeerDone) {
+			fmt.Fprintf(conn, "error: expected %q, %q or %q followed by a name\n", PeerDraining, PeerReady, PeerDone)
+			continue
+		}
+		c.mu.Lock()
+		c.states[name] = state
+		c.checkDone()
+		c.mu.Unlock()
+		fmt.Fprintln(conn, "ok")
+	}
+}
+
+// checkDone closes allDone once every expected peer is done. It is called
+// with c.mu held.
+func (c *Coordinator) checkDone() {
+	for p := range c.expected {
+		if c.states[p] != PeerDone {
+			return
+		}
+	}
+	select {
+	case <-c.allDone:
+	default:
+		close(c.allDone)
+	}
+}
+
+// Peers returns the last state reported by every peer, expected or not.
+func (c *Coordinator) Peers() map[string]string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	out := make(map[string]string, len(c.states))
+	for p, s := range c.states {
+		out[p] = s
+	}
+	return out
+}
+
+func (c *Coordinator) Close() error {
+	return c.CloseContext(context.Background())
+}
+
+// CloseContext waits for every expected peer to report done, then stops
+// listening and removes the socket. It returns an error naming the peers
+// that were not done when the wait ended.
+func (c *Coordinator) CloseContext(ctx context.Context) error {
+	if c.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+	}
+	var err error
+	select {
+	case <-c.allDone:
+	case <-ctx.Done():
+		err = fmt.Errorf("peers not done (%s): %w", strings.Join(c.pending(), ", "), ctx.Err())
+	}
+	c.listener.Close()
+	os.Remove(c.path)
+	return err
+}
+
+func (c *Coordinator) Name() string {
+	return "coordinator " + c.path
+}
+
+func (c *Coordinator) pending() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var pending []string
+	for p := range c.expected {
+		if c.states[p] != PeerDone {
+			pending = append(pending, fmt.Sprintf("%s=%s", p, cmp.Or(c.states[p], "unknown")))
+		}
+	}
+	sort.Strings(pending)
+	return pending
+}
+
+// Participant reports the shutdown progress of this process to a Coordinator
+// listening on a shared unix socket. Handled by a Manager, it announces
+// draining in the drain phase and done when it is closed, so it should be the
+// last resource passed to Handle. When maintenance mode ends, it announces
+// ready again.
+type Participant struct {
+	path    string
+	name    string
+	timeout time.Duration
+}
+
+// NewParticipant reports progress as name to the coordinator at path.
+func NewParticipant(path, name string) *Participant {
+	return &Participant{path: path, name: name, timeout: 5 * time.Second}
+}
+
+// Announce sends state to the coordinator and waits for its acknowledgement.
+func (p *Participant) Announce(ctx context.Context, state string) error {
+	ctx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "unix", p.path)
+	if err != nil {
+		return fmt.Errorf("announce %s: %w", state, err)
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	if _, err := fmt.Fprintf(conn, "%s %s\n", state, p.name); err != nil {
+		return fmt.Errorf("announce %s: %w", state, err)
+	}
+	reply, err := bufio.NewReader(conn).ReadString('\n')
+	if err != nil {
+		return fmt.Errorf("announce %s: %w", state, err)
+	}
+	if reply = strings.TrimSpace(reply); reply != "ok" {
+		return fmt.Errorf("announce %s: %s", state, reply)
+	}
+	return nil
+}
+
+func (p *Participant) Drain(ctx context.Context) error {
+	return p.Announce(ctx, PeerDraining)
+}
+
+func (p *Participant) Resume() error {
+	return p.Announce(context.Background(), PeerReady)
+}
+
+func (p *Participant) Close() error {
+	return p.CloseContext(context.Background())
+}
+
+// CloseContext announces done, giving up when ctx ends.
+func (p *Participant) CloseContext(ctx context.Context) error {
+	return p.Announce(ctx, PeerDone)
+}
+
+func (p *Participant) Name() string {
+	return "participant " + p.name
+}
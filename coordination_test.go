@@ -0,0 +1,129 @@
+package gracefulshutdown
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newCoordinator(t *testing.T, timeout time.Duration, peers ...string) (*Coordinator, string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "shutdown.sock")
+	c, err := NewCoordinator(path, timeout, peers...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { c.listener.Close() })
+	return c, path
+}
+
+func TestCoordinatorWaitsForPeers(t *testing.T) {
+	c, path := newCoordinator(t, 5*time.Second, "api", "worker")
+	api, worker := NewParticipant(path, "api"), NewParticipant(path, "worker")
+	closed := make(chan error, 1)
+	go func() { closed <- c.Close() }()
+
+	if err := api.Drain(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if err := api.Close(); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case err := <-closed:
+		t.Fatalf("closed before every peer was done: %v", err)
+	case <-time.After(20 * time.Millisecond):
+	}
+	if got := c.Peers(); got["api"] != PeerDone || got["worker"] != "" {
+		t.Errorf("peers = %v", got)
+	}
+
+	if err := worker.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-closed; err != nil {
+		t.Errorf("close = %v", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("socket not removed: %v", err)
+	}
+}
+
+func TestCoordinatorTimeoutNamesPendingPeers(t *testing.T) {
+	c, path := newCoordinator(t, 20*time.Millisecond, "api", "worker")
+	if err := NewParticipant(path, "api").Drain(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	err := c.Close()
+	if err == nil || !strings.Contains(err.Error(), "api=draining, worker=unknown") || !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("close = %v", err)
+	}
+}
+
+func TestCoordinatorRejectsUnknownState(t *testing.T) {
+	_, path := newCoordinator(t, time.Second)
+	err := NewParticipant(path, "api").Announce(context.Background(), "sleeping")
+	if err == nil || !strings.Contains(err.Error(), "error: expected") {
+		t.Errorf("announce = %v", err)
+	}
+}
+
+func TestParticipantAnnouncesReadyAfterMaintenance(t *testing.T) {
+	c, path := newCoordinator(t, time.Second, "api")
+	m := NewManager(&testLogger{})
+	done := handle(m, NewParticipant(path, "api"))
+	waitHandling(t, m)
+
+	if err := m.EnterMaintenance(); err != nil {
+		t.Fatal(err)
+	}
+	if s := c.Peers()["api"]; s != PeerDraining {
+		t.Errorf("state in maintenance = %q", s)
+	}
+	if err := m.Resume(); err != nil {
+		t.Fatal(err)
+	}
+	if s := c.Peers()["api"]; s != PeerReady {
+		t.Errorf("state after resume = %q", s)
+	}
+
+	m.Trigger("test")
+	<-done
+	if s := c.Peers()["api"]; s != PeerDone {
+		t.Errorf("state after shutdown = %q", s)
+	}
+}
+
+func TestParticipantCloseContextHonoursDeadline(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "shutdown.sock")
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	go func() {
+		// Accept but never acknowledge.
+		conn, err := ln.Accept()
+		if err == nil {
+			defer conn.Close()
+			io.Copy(io.Discard, conn)
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	err = NewParticipant(path, "api").CloseContext(ctx)
+	if !errors.Is(err, os.ErrDeadlineExceeded) {
+		t.Errorf("close = %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("close took %v", elapsed)
+	}
+}
@@ -0,0 +1,48 @@
+package gracefulshutdown
+
+import (
+	"fmt"
+	"os"
+	"time"
+)
+
+// DaemonConfig configures Manager.Daemonize.
+type DaemonConfig struct {
+	// PIDFile receives the pid of the daemon and is removed when its shutdown
+	// sequence completes. No file is written if empty.
+	PIDFile string
+	// Stdout and Stderr are the files the daemon output is appended to;
+	// output is discarded if empty.
+	Stdout string
+	Stderr string
+	// ReadyTimeout is how long the parent waits for the daemon to call
+	// Ready. Defaults to 30 seconds.
+	ReadyTimeout time.Duration
+}
+
+const daemonEnv = "GRACEFULSHUTDOWN_DAEMON_READY_FD"
+
+func (m *Manager) writePIDFile(path string) error {
+	if path == "" {
+		return nil
+	}
+	if err := os.WriteFile(path, []byte(fmt.Sprintf("%d\n", os.Getpid())), 0o644); err != nil {
+		return fmt.Errorf("gracefulshutdown: write pid file: %w", err)
+	}
+	m.mu.Lock()
+	m.pidFile = path
+	m.mu.Unlock()
+	return nil
+}
+
+func (m *Manager) removePIDFile() {
+	m.mu.Lock()
+	path := m.pidFile
+	m.mu.Unlock()
+	if path == "" {
+		return
+	}
+	if err := os.Remove(path); err != nil {
+		m.logger.Error(fmt.Sprintf("error on remove pid file: %s", err.Error()))
+	}
+}
@@ -0,0 +1,10 @@
+//go:build !unix
+
+package gracefulshutdown
+
+import "errors"
+
+// Daemonize is only supported on unix systems.
+func (m *Manager) Daemonize(cfg DaemonConfig) error {
+	return errors.New("gracefulshutdown: daemonize is not supported on this platform")
+}
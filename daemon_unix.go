@@ -0,0 +1,104 @@
+//go:build unix
+
+package gracefulshutdown
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+// Daemonize re-executes the running binary with the same arguments as a
+// detached daemon in a new session, with stdio redirected as configured.
+//
+// In the parent, Daemonize waits until the daemon calls Ready and then exits
+// the process with status 0. It returns an error if the daemon exits or does
+// not become ready within cfg.ReadyTimeout.
+//
+// In the daemon, Daemonize writes the pid file, which is removed when the
+// shutdown sequence completes, connects Ready to the parent, in addition to
+// the readiness fd of WithNotifyFD if set, and returns nil so that the program
+// continues with its usual startup and Handle call.
+func (m *Manager) Daemonize(cfg DaemonConfig) error {
+	if fd, ok := os.LookupEnv(daemonEnv); ok {
+		os.Unsetenv(daemonEnv)
+		n, err := strconv.Atoi(fd)
+		if err != nil {
+			return fmt.Errorf("gracefulshutdown: invalid %s: %w", daemonEnv, err)
+		}
+		m.mu.Lock()
+		m.readyFiles = append(m.readyFiles, os.NewFile(uintptr(n), "daemon-ready"))
+		m.mu.Unlock()
+		return m.writePIDFile(cfg.PIDFile)
+	}
+
+	exe, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("gracefulshutdown: daemonize: %w", err)
+	}
+	r, w, err := os.Pipe()
+	if err != nil {
+		return fmt.Errorf("gracefulshutdown: daemonize: %w", err)
+	}
+	defer r.Close()
+
+	cmd := exec.Command(exe, os.Args[1:]...)
+	cmd.Env = append(os.Environ(), daemonEnv+"=3")
+	cmd.ExtraFiles = []*os.File{w}
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+	for _, out := range []struct {
+		path string
+		dst  *io.Writer
+	}{{cfg.Stdout, &cmd.Stdout}, {cfg.Stderr, &cmd.Stderr}} {
+		if out.path == "" {
+			continue
+		}
+		f, err := os.OpenFile(out.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+		if err != nil {
+			w.Close()
+			return fmt.Errorf("gracefulshutdown: daemonize: %w", err)
+		}
+		defer f.Close()
+		*out.dst = f
+	}
+	if err := cmd.Start(); err != nil {
+		w.Close()
+		return fmt.Errorf("gracefulshutdown: daemonize: %w", err)
+	}
+	w.Close()
+
+	timeout := cfg.ReadyTimeout
+	if timeout <= 0 {
+		timeout = 30 * time.Second
+	}
+	ready := make(chan error, 1)
+	go func() {
+		b := make([]byte, 1)
+		_, err := r.Read(b)
+		ready <- err
+	}()
+	select {
+	case err := <-ready:
+		if err == nil {
+			m.logger.Info(fmt.Sprintf("daemon started with pid %d", cmd.Process.Pid))
+			osExit(0)
+			return nil
+		}
+		if errors.Is(err, io.EOF) {
+			err = errors.New("daemon exited before ready")
+			if werr := cmd.Wait(); werr != nil {
+				err = fmt.Errorf("%w: %w", err, werr)
+			}
+		}
+		return fmt.Errorf("gracefulshutdown: daemonize: %w", err)
+	case <-time.After(timeout):
+		cmd.Process.Kill()
+		cmd.Wait()
+		return fmt.Errorf("gracefulshutdown: daemonize: daemon not ready after %v", timeout)
+	}
+}
@@ -0,0 +1,155 @@
+//go:build unix
+
+package gracefulshutdown
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// daemonHelperEnv makes the test binary, re-executed by Daemonize, act as the
+// daemon: "ready" reports readiness, "exit" exits before and "hang" never
+// reports it.
+const daemonHelperEnv = "GRACEFULSHUTDOWN_TEST_DAEMON"
+
+func TestMain(m *testing.M) {
+	if mode := os.Getenv(daemonHelperEnv); mode != "" && os.Getenv(daemonEnv) != "" {
+		runDaemonHelper(mode)
+		return
+	}
+	os.Exit(m.Run())
+}
+
+func runDaemonHelper(mode string) {
+	m := NewManager(&testLogger{})
+	if err := m.Daemonize(DaemonConfig{PIDFile: os.Getenv(daemonHelperEnv + "_PID")}); err != nil {
+		os.Exit(4)
+	}
+	switch mode {
+	case "ready":
+		fmt.Println("daemon ready")
+		m.Ready()
+	case "exit":
+		os.Exit(3)
+	case "hang":
+		select {}
+	}
+}
+
+// daemonize runs Daemonize in the parent with the test binary as the daemon,
+// returning the exit statuses the parent would have used and its error.
+func daemonize(t *testing.T, mode string, cfg DaemonConfig) ([]int, error) {
+	var exits []int
+	osExit = func(code int) { exits = append(exits, code) }
+	t.Cleanup(func() { osExit = os.Exit })
+	t.Setenv(daemonHelperEnv, mode)
+	t.Setenv(daemonHelperEnv+"_PID", cfg.PIDFile)
+	err := NewManager(&testLogger{}).Daemonize(cfg)
+	return exits, err
+}
+
+func TestDaemonizeParentExitsWhenReady(t *testing.T) {
+	dir := t.TempDir()
+	cfg := DaemonConfig{PIDFile: filepath.Join(dir, "app.pid"), Stdout: filepath.Join(dir, "out.log")}
+	exits, err := daemonize(t, "ready", cfg)
+	if err != nil || !slices.Equal(exits, []int{0}) {
+		t.Fatalf("Daemonize = %v, exits %v", err, exits)
+	}
+	b, err := os.ReadFile(cfg.PIDFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pid := strings.TrimSpace(string(b)); pid == strconv.Itoa(os.Getpid()) {
+		t.Errorf("pid file names the parent")
+	}
+	out, _ := os.ReadFile(cfg.Stdout)
+	if !strings.Contains(string(out), "daemon ready") {
+		t.Errorf("daemon output not redirected: %q", out)
+	}
+}
+
+func TestDaemonizeDaemonExitsBeforeReady(t *testing.T) {
+	exits, err := daemonize(t, "exit", DaemonConfig{})
+	if err == nil || !strings.Contains(err.Error(), "daemon exited before ready") || !strings.Contains(err.Error(), "exit status 3") {
+		t.Errorf("Daemonize = %v", err)
+	}
+	if len(exits) != 0 {
+		t.Errorf("parent exited with %v", exits)
+	}
+}
+
+func TestDaemonizeReadyTimeout(t *testing.T) {
+	start := time.Now()
+	exits, err := daemonize(t, "hang", DaemonConfig{ReadyTimeout: 200 * time.Millisecond})
+	if err == nil || !strings.Contains(err.Error(), "daemon not ready after 200ms") {
+		t.Errorf("Daemonize = %v", err)
+	}
+	if len(exits) != 0 || time.Since(start) > 5*time.Second {
+		t.Errorf("parent exited with %v after %v", exits, time.Since(start))
+	}
+}
+
+func TestDaemonizeAlsoNotifiesReadinessFD(t *testing.T) {
+	notifyR, notifyFD := pipe(t)
+	parentR, parentFD := pipe(t)
+	t.Setenv(daemonEnv, strconv.Itoa(parentFD))
+	m := NewManager(&testLogger{}, WithNotifyFD(notifyFD, -1))
+	if err := m.Daemonize(DaemonConfig{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Ready(); err != nil {
+		t.Fatal(err)
+	}
+	for name, r := range map[string]*os.File{"readiness fd": notifyR, "parent": parentR} {
+		if got, _ := io.ReadAll(r); string(got) != "\n" {
+			t.Errorf("%s received %q", name, got)
+		}
+	}
+}
+
+func TestDaemonizeInDaemon(t *testing.T) {
+	readyR, readyFD := pipe(t)
+	t.Setenv(daemonEnv, strconv.Itoa(readyFD))
+	pidFile := filepath.Join(t.TempDir(), "app.pid")
+	m := NewManager(&testLogger{})
+
+	if err := m.Daemonize(DaemonConfig{PIDFile: pidFile}); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := os.LookupEnv(daemonEnv); ok {
+		t.Errorf("%s left in the environment", daemonEnv)
+	}
+	b, err := os.ReadFile(pidFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.TrimSpace(string(b)); got != strconv.Itoa(os.Getpid()) {
+		t.Errorf("pid file = %q", got)
+	}
+
+	if err := m.Ready(); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := io.ReadAll(readyR); string(got) != "\n" {
+		t.Errorf("parent received %q", got)
+	}
+
+	shutdown(t, m, &fakeResource{name: "db"})
+	if _, err := os.Stat(pidFile); !os.IsNotExist(err) {
+		t.Errorf("pid file not removed: %v", err)
+	}
+}
+
+func TestDaemonizeInvalidReadyFD(t *testing.T) {
+	t.Setenv(daemonEnv, "stdout")
+	if err := NewManager(&testLogger{}).Daemonize(DaemonConfig{}); err == nil {
+		t.Error("invalid ready fd accepted")
+	}
+}
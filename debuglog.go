@@ -0,0 +1,119 @@
+package gracefulshutdown
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+)
+
+// DebugBuffer is a slog.Handler that forwards records enabled by the wrapped
+// handler and keeps the most recent records below its level in a bounded ring
+// buffer. The buffered records are emitted by Dump, which the shutdown
+// sequence calls when the Report contains failures, including timeouts, and
+// when a second termination signal forces the process to exit.
+type DebugBuffer struct {
+	next  slog.Handler
+	level slog.Leveler
+	ring  *debugRing
+}
+
+type debugEntry struct {
+	handler slog.Handler
+	record  slog.Record
+}
+
+type debugRing struct {
+	mu      sync.Mutex
+	entries []debugEntry
+	start   int
+	count   int
+}
+
+// NewDebugBuffer wraps next and buffers up to size records at or above level
+// that next would discard. A nil level means slog.LevelDebug.
+func NewDebugBuffer(next slog.Handler, size int, level slog.Leveler) *DebugBuffer {
+	if size < 1 {
+		size = 1
+	}
+	if level == nil {
+		level = slog.LevelDebug
+	}
+	return &DebugBuffer{
+		next:  next,
+		level: level,
+		ring:  &debugRing{entries: make([]debugEntry, size)},
+	}
+}
+
+func (b *DebugBuffer) Enabled(ctx context.Context, level slog.Level) bool {
+	return level >= b.level.Level() || b.next.Enabled(ctx, level)
+}
+
+func (b *DebugBuffer) Handle(ctx context.Context, r slog.Record) error {
+	if b.next.Enabled(ctx, r.Level) {
+		return b.next.Handle(ctx, r)
+	}
+	b.ring.push(debugEntry{handler: b.next, record: r.Clone()})
+	return nil
+}
+
+func (b *DebugBuffer) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &DebugBuffer{next: b.next.WithAttrs(attrs), level: b.level, ring: b.ring}
+}
+
+func (b *DebugBuffer) WithGroup(name string) slog.Handler {
+	return &DebugBuffer{next: b.next.WithGroup(name), level: b.level, ring: b.ring}
+}
+
+// Dump emits every buffered record, oldest first, through the wrapped handler
+// and empties the buffer.
+func (b *DebugBuffer) Dump(ctx context.Context) error {
+	var errs []error
+	for _, e := range b.ring.drain() {
+		if err := e.handler.Handle(ctx, e.record); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (r *debugRing) push(e debugEntry) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	i := (r.start + r.count) % len(r.entries)
+	r.entries[i] = e
+	if r.count < len(r.entries) {
+		r.count++
+		return
+	}
+	r.start = (r.start + 1) % len(r.entries)
+}
+
+func (r *debugRing) drain() []debugEntry {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	out := make([]debugEntry, 0, r.count)
+	for i := 0; i < r.count; i++ {
+		j := (r.start + i) % len(r.entries)
+		out = append(out, r.entries[j])
+		r.entries[j] = debugEntry{}
+	}
+	r.start, r.count = 0, 0
+	return out
+}
+
+// dumpDebug emits the records buffered by logger, if it is backed by a
+// DebugBuffer.
+func dumpDebug(logger Logger) {
+	var h any = logger
+	if l, ok := logger.(interface{ Handler() slog.Handler }); ok {
+		h = l.Handler()
+	}
+	if d, ok := h.(interface{ Dump(context.Context) error }); ok {
+		if err := d.Dump(context.Background()); err != nil {
+			logger.Error(fmt.Sprintf("error on dump debug records: %s", err.Error()))
+		}
+	}
+}
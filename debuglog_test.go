@@ -0,0 +1,89 @@
+package gracefulshutdown
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func newBufferedLogger(size int) (*slog.Logger, *DebugBuffer, *bytes.Buffer) {
+	var out bytes.Buffer
+	next := slog.NewTextHandler(&out, &slog.HandlerOptions{Level: slog.LevelInfo})
+	buf := NewDebugBuffer(next, size, nil)
+	return slog.New(buf), buf, &out
+}
+
+func TestDebugBufferForwardsEnabledRecords(t *testing.T) {
+	logger, _, out := newBufferedLogger(4)
+	logger.Info("visible")
+	logger.Debug("hidden")
+	if !strings.Contains(out.String(), "visible") {
+		t.Errorf("info record not forwarded: %q", out.String())
+	}
+	if strings.Contains(out.String(), "hidden") {
+		t.Errorf("debug record forwarded before dump: %q", out.String())
+	}
+}
+
+func TestDebugBufferDumpKeepsMostRecentInOrder(t *testing.T) {
+	logger, buf, out := newBufferedLogger(2)
+	logger = logger.With("component", "db")
+	logger.Debug("first")
+	logger.Debug("second")
+	logger.Debug("third")
+	if err := buf.Dump(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	got := out.String()
+	if strings.Contains(got, "first") {
+		t.Errorf("oldest record not evicted: %q", got)
+	}
+	second, third := strings.Index(got, "second"), strings.Index(got, "third")
+	if second < 0 || third < 0 || second > third {
+		t.Errorf("want second then third, got %q", got)
+	}
+	if !strings.Contains(got, "component=db") {
+		t.Errorf("attributes lost: %q", got)
+	}
+
+	out.Reset()
+	buf.Dump(context.Background())
+	if out.Len() != 0 {
+		t.Errorf("dump did not empty the buffer: %q", out.String())
+	}
+}
+
+func TestDebugBufferLevel(t *testing.T) {
+	var out bytes.Buffer
+	next := slog.NewTextHandler(&out, &slog.HandlerOptions{Level: slog.LevelWarn})
+	buf := NewDebugBuffer(next, 4, slog.LevelInfo)
+	logger := slog.New(buf)
+	logger.Debug("too low")
+	logger.Info("kept")
+	buf.Dump(context.Background())
+	if strings.Contains(out.String(), "too low") || !strings.Contains(out.String(), "kept") {
+		t.Errorf("got %q", out.String())
+	}
+}
+
+func TestShutdownDumpsDebugRecordsOnlyOnFailure(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		resource *fakeResource
+		dumped   bool
+	}{
+		{"clean", &fakeResource{name: "db"}, false},
+		{"failed", failing("db"), true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			logger, _, out := newBufferedLogger(8)
+			logger.Debug("pool stats")
+			shutdown(t, NewManager(logger), tc.resource)
+			if got := strings.Contains(out.String(), "pool stats"); got != tc.dumped {
+				t.Errorf("dumped = %v, want %v:\n%s", got, tc.dumped, out.String())
+			}
+		})
+	}
+}
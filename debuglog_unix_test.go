@@ -0,0 +1,73 @@
+//go:build unix
+
+package gracefulshutdown
+
+import (
+	"os"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestSecondSignalForcesExit(t *testing.T) {
+	var mu sync.Mutex
+	exitCode := -1
+	osExit = func(code int) {
+		mu.Lock()
+		defer mu.Unlock()
+		exitCode = code
+	}
+	t.Cleanup(func() { osExit = os.Exit })
+
+	logger, _, out := newBufferedLogger(8)
+	logger.Debug("pool stats")
+	secret := Secret("key")
+	m := NewManager(logger, WithZeroizers(secret))
+	stuck := &fakeResource{name: "stuck", block: make(chan struct{})}
+	m.Trigger("test")
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		m.HandleAndTerminate(stuck)
+	}()
+	waitFor(t, "close to start", func() bool { return len(stuck.called()) > 0 })
+
+	syscall.Kill(syscall.Getpid(), syscall.SIGTERM)
+	waitFor(t, "forced exit", func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return exitCode == ExitForced
+	})
+	close(stuck.block)
+	<-done
+
+	if !strings.Contains(out.String(), "pool stats") {
+		t.Errorf("debug records not dumped on forced exit:\n%s", out.String())
+	}
+	if string(secret) != "\x00\x00\x00" {
+		t.Errorf("secret not zeroized on forced exit: %q", secret)
+	}
+}
+
+func TestSecondSignalIgnoredByHandle(t *testing.T) {
+	exited := make(chan int, 1)
+	osExit = func(code int) { exited <- code }
+	t.Cleanup(func() { osExit = os.Exit })
+
+	m := NewManager(&testLogger{})
+	stuck := &fakeResource{name: "stuck", block: make(chan struct{})}
+	m.Trigger("test")
+	done := handle(m, stuck)
+	waitFor(t, "close to start", func() bool { return len(stuck.called()) > 0 })
+
+	syscall.Kill(syscall.Getpid(), syscall.SIGTERM)
+	select {
+	case code := <-exited:
+		t.Fatalf("Handle exited the process with %d", code)
+	case <-time.After(50 * time.Millisecond):
+	}
+	close(stuck.block)
+	<-done
+}
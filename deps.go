@@ -0,0 +1,195 @@
+package gracefulshutdown
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"sync"
+)
+
+// Tracked wraps a resource handled by a Manager so that its shutdown can be
+// ordered after the resources that depend on it. Dependencies are declared
+// with DependsOn and, when WithDependencyInference is set, observed from the
+// calls made through contexts returned by Enter.
+type Tracked struct {
+	Closeable
+	name  string
+	graph *depGraph
+}
+
+type depGraph struct {
+	mu       sync.Mutex
+	infer    bool
+	declared map[*Tracked]map[*Tracked]bool
+	observed map[*Tracked]map[*Tracked]bool
+}
+
+type trackedKey struct{}
+
+// WithDependencyInference records which tracked resources are invoked while
+// another tracked resource serves a call, orders the shutdown by the observed
+// graph and warns about observed edges that were not declared with DependsOn.
+func WithDependencyInference() Option {
+	return func(m *Manager) {
+		m.deps.infer = true
+	}
+}
+
+// Track wraps c under name for dependency ordering.
+func (m *Manager) Track(name string, c Closeable) *Tracked {
+	return &Tracked{Closeable: c, name: name, graph: &m.deps}
+}
+
+func (t *Tracked) Name() string {
+	return t.name
+}
+
+// CloseContext is needed because the embedded Closeable only promotes Close:
+// without it, a tracked ContextCloseable would lose the shutdown deadline and
+// logger passed by the manager.
+func (t *Tracked) CloseContext(ctx context.Context) error {
+	return closeContext(ctx, t.Closeable)
+}
+
+// Unwrap returns the wrapped resource.
+func (t *Tracked) Unwrap() Closeable {
+	return t.Closeable
+}
+
+// DependsOn declares that t uses deps, so t is shut down before them.
+func (t *Tracked) DependsOn(deps ...*Tracked) *Tracked {
+	t.graph.mu.Lock()
+	defer t.graph.mu.Unlock()
+	for _, d := range deps {
+		addEdge(&t.graph.declared, t, d)
+	}
+	return t
+}
+
+// Enter marks the start of a call served by t. When dependency inference is
+// enabled and ctx was returned by Enter on another tracked resource, that
+// resource is recorded as depending on t. Calls t makes to other tracked
+// resources must use the returned context.
+func (t *Tracked) Enter(ctx context.Context) context.Context {
+	if caller, ok := ctx.Value(trackedKey{}).(*Tracked); ok && caller != t && t.graph.infer {
+		t.graph.mu.Lock()
+		addEdge(&t.graph.observed, caller, t)
+		t.graph.mu.Unlock()
+	}
+	return context.WithValue(ctx, trackedKey{}, t)
+}
+
+func addEdge(edges *map[*Tracked]map[*Tracked]bool, from, to *Tracked) {
+	if *edges == nil {
+		*edges = map[*Tracked]map[*Tracked]bool{}
+	}
+	if (*edges)[from] == nil {
+		(*edges)[from] = map[*Tracked]bool{}
+	}
+	(*edges)[from][to] = true
+}
+
+// handled is a resource together with its position in the list passed to
+// Handle, which logs and reports use to identify it whatever the shutdown
+// order.
+type handled struct {
+	index    int
+	resource Closeable
+}
+
+// inRegistrationOrder returns resources in the order they were passed to
+// Handle.
+func inRegistrationOrder(resources []Closeable) []handled {
+	out := make([]handled, len(resources))
+	for i, c := range resources {
+		out[i] = handled{index: i, resource: c}
+	}
+	return out
+}
+
+// shutdownOrder returns resources reordered so that every tracked resource
+// comes before the tracked resources it depends on, keeping the registration
+// order otherwise. Resources in a dependency cycle keep their registration
+// order.
+func (m *Manager) shutdownOrder(resources []Closeable) []handled {
+	g := &m.deps
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if len(g.declared) == 0 && len(g.observed) == 0 {
+		return inRegistrationOrder(resources)
+	}
+
+	index := map[*Tracked]int{}
+	for i, c := range resources {
+		if t, ok := as[*Tracked](c); ok {
+			index[t] = i
+		}
+	}
+	dependents := make([]int, len(resources))
+	uses := make([][]int, len(resources))
+	for _, edges := range []map[*Tracked]map[*Tracked]bool{g.declared, g.observed} {
+		for from, tos := range edges {
+			for to := range tos {
+				i, ok1 := index[from]
+				j, ok2 := index[to]
+				if !ok1 || !ok2 || slices.Contains(uses[i], j) {
+					continue
+				}
+				uses[i] = append(uses[i], j)
+				dependents[j]++
+			}
+		}
+	}
+
+	var ready, order []int
+	for i := range resources {
+		if dependents[i] == 0 {
+			ready = append(ready, i)
+		}
+	}
+	done := make([]bool, len(resources))
+	for len(ready) > 0 {
+		sort.Ints(ready)
+		i := ready[0]
+		ready = ready[1:]
+		done[i] = true
+		order = append(order, i)
+		for _, j := range uses[i] {
+			if dependents[j]--; dependents[j] == 0 {
+				ready = append(ready, j)
+			}
+		}
+	}
+	for i := range resources {
+		if !done[i] {
+			m.logger.Warn(fmt.Sprintf("dependency cycle involving resource %s", resourceName(resources[i])))
+			order = append(order, i)
+		}
+	}
+
+	ordered := make([]handled, len(resources))
+	for k, i := range order {
+		ordered[k] = handled{index: i, resource: resources[i]}
+	}
+	return ordered
+}
+
+// verifyDependencies warns about observed dependencies that were not declared.
+func (m *Manager) verifyDependencies() {
+	g := &m.deps
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	var warnings []string
+	for from, tos := range g.observed {
+		for to := range tos {
+			if !g.declared[from][to] {
+				warnings = append(warnings, fmt.Sprintf("undeclared dependency: %s depends on %s", from.name, to.name))
+			}
+		}
+	}
+	sort.Strings(warnings)
+	for _, w := range warnings {
+		m.logger.Warn(w)
+	}
+}
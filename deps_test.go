@@ -0,0 +1,96 @@
+package gracefulshutdown
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func closeOrder(report Report) []string {
+	var names []string
+	for _, res := range report.Resources {
+		if res.Phase == PhaseClose {
+			names = append(names, res.Name)
+		}
+	}
+	return names
+}
+
+func TestDependsOnOrdersShutdown(t *testing.T) {
+	m := NewManager(&testLogger{})
+	db := m.Track("db", &fakeResource{})
+	cache := m.Track("cache", &fakeResource{})
+	api := m.Track("api", &fakeResource{}).DependsOn(db, cache)
+
+	report := shutdown(t, m, db, cache, api)
+	if got := closeOrder(report); !slices.Equal(got, []string{"api", "db", "cache"}) {
+		t.Errorf("close order = %v", got)
+	}
+}
+
+func TestObservedDependenciesOrderShutdownAndWarn(t *testing.T) {
+	logger := &testLogger{}
+	m := NewManager(logger, WithDependencyInference())
+	db := m.Track("db", &fakeResource{})
+	api := m.Track("api", &fakeResource{})
+
+	ctx := api.Enter(context.Background())
+	db.Enter(ctx)
+
+	report := shutdown(t, m, db, api)
+	if got := closeOrder(report); !slices.Equal(got, []string{"api", "db"}) {
+		t.Errorf("close order = %v", got)
+	}
+	if !logger.contains("WARN undeclared dependency: api depends on db") {
+		t.Errorf("undeclared dependency not reported:\n%s", logger)
+	}
+}
+
+func TestObservedDependenciesIgnoredWithoutInference(t *testing.T) {
+	m := NewManager(&testLogger{})
+	db := m.Track("db", &fakeResource{})
+	api := m.Track("api", &fakeResource{})
+	db.Enter(api.Enter(context.Background()))
+
+	if got := closeOrder(shutdown(t, m, db, api)); !slices.Equal(got, []string{"db", "api"}) {
+		t.Errorf("close order = %v", got)
+	}
+}
+
+func TestDependencyCycleKeepsRegistrationOrder(t *testing.T) {
+	logger := &testLogger{}
+	m := NewManager(logger)
+	a := m.Track("a", &fakeResource{})
+	b := m.Track("b", &fakeResource{}).DependsOn(a)
+	a.DependsOn(b)
+
+	if got := closeOrder(shutdown(t, m, a, b)); !slices.Equal(got, []string{"a", "b"}) {
+		t.Errorf("close order = %v", got)
+	}
+	if !logger.contains("dependency cycle involving resource a") {
+		t.Errorf("cycle not reported:\n%s", logger)
+	}
+}
+
+func TestReorderedResourcesKeepRegistrationIndex(t *testing.T) {
+	logger := &testLogger{}
+	m := NewManager(logger)
+	db := m.Track("db", CloseOnce(&fakeResource{}))
+	api := m.Track("api", &fakeResource{}).DependsOn(db)
+
+	report := shutdown(t, m, db, api)
+	var got []ResourceReport
+	for _, res := range report.Resources {
+		got = append(got, ResourceReport{Index: res.Index, Name: res.Name})
+	}
+	if want := []ResourceReport{{Index: 1, Name: "api"}, {Index: 0, Name: "db"}}; !slices.Equal(got, want) {
+		t.Errorf("reported %+v, want %+v", got, want)
+	}
+	if i, j := strings.Index(logger.String(), "trying to close resource 1"), strings.Index(logger.String(), "trying to close resource 0"); i < 0 || j < i {
+		t.Errorf("close lines do not use the registration index:\n%s", logger)
+	}
+	if states := m.States(); len(states) != 1 || states[0].Index != 0 || states[0].Name != "db" {
+		t.Errorf("states = %+v", states)
+	}
+}
@@ -0,0 +1,163 @@
+// Package gracefulshutdowntest provides a conformance suite for
+// gracefulshutdown.Closeable implementations.
+package gracefulshutdowntest
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"runtime/pprof"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	gracefulshutdown "github.com/eviccari/graceful-shutdown"
+)
+
+const (
+	// closeTimeout bounds every Close call made by the suite.
+	closeTimeout = 5 * time.Second
+	// deadlineGrace is how long CloseContext may overrun an expired context.
+	deadlineGrace = 500 * time.Millisecond
+	// leakTimeout is how long goroutines started by a resource may take to
+	// exit after it is closed.
+	leakTimeout = 2 * time.Second
+	concurrency = 8
+)
+
+// RunCloseableTests checks that the resources returned by factory can be
+// handled safely by a Manager: Close returns promptly, is safe to call again
+// and from several goroutines at once, and leaves no goroutine behind. If the
+// resource is a gracefulshutdown.ContextCloseable, CloseContext must also
+// return promptly once its context is done. Each check uses a new resource.
+//
+// Data races between concurrent Close calls are only reported when the tests
+// run with -race. Resources that are not idempotent can be wrapped with
+// gracefulshutdown.CloseOnce.
+func RunCloseableTests(t *testing.T, factory func() gracefulshutdown.Closeable) {
+	t.Helper()
+
+	t.Run("Close", func(t *testing.T) {
+		c := factory()
+		if r := call(closeTimeout, c.Close); !r.check(t, "Close") {
+			t.FailNow()
+		}
+	})
+
+	t.Run("RepeatedClose", func(t *testing.T) {
+		c := factory()
+		first := call(closeTimeout, c.Close)
+		if !first.check(t, "first Close") {
+			t.FailNow()
+		}
+		second := call(closeTimeout, c.Close)
+		if !second.check(t, "second Close") {
+			t.FailNow()
+		}
+		if first.err == nil && second.err != nil {
+			t.Errorf("second Close returned %v after the first succeeded; wrap the resource with gracefulshutdown.CloseOnce", second.err)
+		}
+	})
+
+	t.Run("ConcurrentClose", func(t *testing.T) {
+		c := factory()
+		var wg sync.WaitGroup
+		results := make([]result, concurrency)
+		for i := 0; i < concurrency; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				results[i] = call(closeTimeout, c.Close)
+			}(i)
+		}
+		wg.Wait()
+		for i, r := range results {
+			if r.check(t, fmt.Sprintf("concurrent Close %d", i)) && fmt.Sprint(r.err) != fmt.Sprint(results[0].err) {
+				t.Errorf("concurrent Close %d returned %v, concurrent Close 0 returned %v; wrap the resource with gracefulshutdown.CloseOnce", i, r.err, results[0].err)
+			}
+		}
+	})
+
+	t.Run("CloseContextDeadline", func(t *testing.T) {
+		cc, ok := factory().(gracefulshutdown.ContextCloseable)
+		if !ok {
+			t.Skip("resource is not a gracefulshutdown.ContextCloseable")
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		call(50*time.Millisecond+deadlineGrace, func() error { return cc.CloseContext(ctx) }).check(t, "CloseContext past its deadline")
+	})
+
+	t.Run("CloseContextCancelled", func(t *testing.T) {
+		cc, ok := factory().(gracefulshutdown.ContextCloseable)
+		if !ok {
+			t.Skip("resource is not a gracefulshutdown.ContextCloseable")
+		}
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		call(deadlineGrace, func() error { return cc.CloseContext(ctx) }).check(t, "CloseContext with a cancelled context")
+	})
+
+	t.Run("GoroutineLeak", func(t *testing.T) {
+		before := runtime.NumGoroutine()
+		c := factory()
+		if r := call(closeTimeout, c.Close); !r.check(t, "Close") {
+			t.FailNow()
+		}
+		deadline := time.Now().Add(leakTimeout)
+		for runtime.NumGoroutine() > before {
+			if time.Now().After(deadline) {
+				var dump strings.Builder
+				pprof.Lookup("goroutine").WriteTo(&dump, 1)
+				t.Errorf("%d goroutines still running %v after Close:\n%s", runtime.NumGoroutine()-before, leakTimeout, dump.String())
+				return
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	})
+}
+
+// result is the outcome of a call made by the suite.
+type result struct {
+	timeout  time.Duration
+	returned bool
+	err      error
+	panicked any
+}
+
+// call runs f in a new goroutine and waits up to timeout for it to return or
+// panic. Nothing is reported from that goroutine; the caller checks the result.
+func call(timeout time.Duration, f func() error) result {
+	done := make(chan result, 1)
+	go func() {
+		defer func() {
+			if p := recover(); p != nil {
+				done <- result{returned: true, panicked: p}
+			}
+		}()
+		done <- result{returned: true, err: f()}
+	}()
+	select {
+	case r := <-done:
+		r.timeout = timeout
+		return r
+	case <-time.After(timeout):
+		return result{timeout: timeout}
+	}
+}
+
+// check reports a call that did not return in time or panicked, and reports
+// whether it returned normally.
+func (r result) check(t *testing.T, what string) bool {
+	t.Helper()
+	switch {
+	case !r.returned:
+		t.Errorf("%s did not return within %v", what, r.timeout)
+	case r.panicked != nil:
+		t.Errorf("%s panicked: %v", what, r.panicked)
+	default:
+		return true
+	}
+	return false
+}
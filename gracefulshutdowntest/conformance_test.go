@@ -0,0 +1,64 @@
+package gracefulshutdowntest
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"testing"
+	"time"
+
+	gracefulshutdown "github.com/eviccari/graceful-shutdown"
+)
+
+// flaky fails every Close after the first, like many raw resources do.
+type flaky struct{ closed bool }
+
+func (f *flaky) Close() error {
+	if f.closed {
+		return errors.New("already closed")
+	}
+	f.closed = true
+	return nil
+}
+
+func TestCloseOnceConformance(t *testing.T) {
+	RunCloseableTests(t, func() gracefulshutdown.Closeable {
+		return gracefulshutdown.CloseOnce(&flaky{})
+	})
+}
+
+func TestCoordinatorConformance(t *testing.T) {
+	dir := t.TempDir()
+	n := 0
+	RunCloseableTests(t, func() gracefulshutdown.Closeable {
+		n++
+		c, err := gracefulshutdown.NewCoordinator(filepath.Join(dir, fmt.Sprintf("%d.sock", n)), time.Second)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return c
+	})
+}
+
+func TestTransportConformance(t *testing.T) {
+	RunCloseableTests(t, func() gracefulshutdown.Closeable {
+		return gracefulshutdown.NewTransport(&http.Transport{})
+	})
+}
+
+func TestCallReportsPanicAndTimeout(t *testing.T) {
+	r := call(time.Second, func() error { panic("boom") })
+	if !r.returned || r.panicked != "boom" {
+		t.Errorf("panic result = %+v", r)
+	}
+	block := make(chan struct{})
+	defer close(block)
+	r = call(10*time.Millisecond, func() error { <-block; return nil })
+	if r.returned {
+		t.Errorf("blocked call returned: %+v", r)
+	}
+	if r = call(time.Second, func() error { return errors.New("x") }); !r.returned || r.err == nil {
+		t.Errorf("error result = %+v", r)
+	}
+}
@@ -0,0 +1,141 @@
+package gracefulshutdown
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// testLogger records every message with its level.
+type testLogger struct {
+	mu    sync.Mutex
+	lines []string
+}
+
+func (l *testLogger) Debug(msg string, _ ...any) { l.log("DEBUG", msg) }
+func (l *testLogger) Info(msg string, _ ...any)  { l.log("INFO", msg) }
+func (l *testLogger) Warn(msg string, _ ...any)  { l.log("WARN", msg) }
+func (l *testLogger) Error(msg string, _ ...any) { l.log("ERROR", msg) }
+
+func (l *testLogger) log(level, msg string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.lines = append(l.lines, level+" "+msg)
+}
+
+func (l *testLogger) String() string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return strings.Join(l.lines, "\n")
+}
+
+func (l *testLogger) contains(substr string) bool {
+	return strings.Contains(l.String(), substr)
+}
+
+// shutdown runs a complete sequence on m for resources, triggered
+// programmatically, and returns its report.
+func shutdown(t *testing.T, m *Manager, resources ...Closeable) Report {
+	t.Helper()
+	m.Trigger("test")
+	select {
+	case <-handle(m, resources...):
+	case <-time.After(10 * time.Second):
+		t.Fatal("shutdown sequence did not complete")
+	}
+	return m.Report()
+}
+
+func handle(m *Manager, resources ...Closeable) <-chan bool {
+	done := make(chan bool, 1)
+	go func() { done <- <-m.Handle(resources...) }()
+	return done
+}
+
+// waitFor polls cond until it holds or fails the test after a few seconds.
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// waitHandling waits until m has started handling its resources.
+func waitHandling(t *testing.T, m *Manager) {
+	t.Helper()
+	waitFor(t, "handling", func() bool {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		return m.handling
+	})
+}
+
+// fakeResource records the calls made by the manager and can fail or block
+// any of them.
+type fakeResource struct {
+	name     string
+	closeErr error
+	block    chan struct{}
+
+	mu    sync.Mutex
+	calls []string
+}
+
+func (r *fakeResource) Name() string { return r.name }
+
+func (r *fakeResource) Close() error {
+	r.record("close")
+	if r.block != nil {
+		<-r.block
+	}
+	return r.closeErr
+}
+
+func (r *fakeResource) record(call string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls = append(r.calls, call)
+}
+
+func (r *fakeResource) called() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.calls...)
+}
+
+// drainingResource is a fakeResource that takes part in every optional phase.
+type drainingResource struct {
+	fakeResource
+	drain func(ctx context.Context) error
+}
+
+func (r *drainingResource) Pause() error  { r.record("pause"); return nil }
+func (r *drainingResource) Resume() error { r.record("resume"); return nil }
+
+func (r *drainingResource) Drain(ctx context.Context) error {
+	r.record("drain")
+	if r.drain != nil {
+		return r.drain(ctx)
+	}
+	return nil
+}
+
+// blockUntilDone is a drain that only returns when its context is done.
+func blockUntilDone(ctx context.Context) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+var errBoom = errors.New("boom")
+
+func failing(name string) *fakeResource {
+	return &fakeResource{name: name, closeErr: fmt.Errorf("%s: %w", name, errBoom)}
+}
@@ -0,0 +1,197 @@
+package gracefulshutdown
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+var (
+	ErrNotHandling     = errors.New("gracefulshutdown: manager is not handling resources")
+	ErrMaintenanceBusy = errors.New("gracefulshutdown: maintenance mode is changing")
+)
+
+// WithMaintenanceSignals makes the manager enter maintenance mode when enter
+// is received and leave it when resume is received.
+func WithMaintenanceSignals(enter, resume os.Signal) Option {
+	return func(m *Manager) {
+		m.maintenanceEnter = enter
+		m.maintenanceResume = resume
+	}
+}
+
+// transition is a maintenance mode change in progress. Its phases run
+// without holding the manager lock. When shutdown starts, it takes over a
+// change in progress: the phases are bounded by the shutdown drain deadline
+// instead of being cancelled, and their results are added to the Report.
+type transition struct {
+	ctx     *handoffContext
+	done    chan struct{}
+	results []ResourceReport
+}
+
+// handoffContext is the context of a maintenance mode change. It is never
+// done on its own; once shutdown takes the change over, it is done when the
+// context of the shutdown drain is, with the same error.
+type handoffContext struct {
+	context.Context
+	done chan struct{}
+	once sync.Once
+
+	mu       sync.Mutex
+	err      error
+	deadline time.Time
+	hasLimit bool
+}
+
+func newHandoffContext(parent context.Context) *handoffContext {
+	return &handoffContext{Context: parent, done: make(chan struct{})}
+}
+
+func (c *handoffContext) Done() <-chan struct{} {
+	return c.done
+}
+
+func (c *handoffContext) Err() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.err
+}
+
+func (c *handoffContext) Deadline() (time.Time, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.deadline, c.hasLimit
+}
+
+// follow makes c done when ctx is.
+func (c *handoffContext) follow(ctx context.Context) (stop func() bool) {
+	c.mu.Lock()
+	c.deadline, c.hasLimit = ctx.Deadline()
+	c.mu.Unlock()
+	return context.AfterFunc(ctx, func() { c.end(ctx.Err()) })
+}
+
+func (c *handoffContext) end(err error) {
+	c.once.Do(func() {
+		c.mu.Lock()
+		c.err = err
+		c.mu.Unlock()
+		close(c.done)
+	})
+}
+
+// EnterMaintenance takes the instance out of rotation by running the
+// readiness, pause and drain phases, and holds there without closing any
+// resource until Resume is called or shutdown starts. Shutdown lets a drain
+// still in progress finish within the limits of its own drain phase.
+func (m *Manager) EnterMaintenance() error {
+	m.auditCaller("maintenance", "")
+	return m.enterMaintenance()
+}
+
+func (m *Manager) enterMaintenance() error {
+	ctx, t, err := m.beginTransition(false)
+	if t == nil {
+		return err
+	}
+	defer m.endTransition(t)
+
+	m.logger.Warn("entering maintenance mode")
+	m.progress("draining")
+	m.readiness(false)
+	resources := m.shutdownOrder(m.handledResources())
+	t.results = m.runPhase(ctx, PhasePause, resources)
+	t.results = append(t.results, m.runPhase(ctx, PhaseDrain, resources)...)
+	m.mu.Lock()
+	m.drained = true
+	m.mu.Unlock()
+	return phaseErrors(t.results)
+}
+
+// Resume leaves maintenance mode, calling Resume on every Resumable resource
+// and restoring readiness.
+func (m *Manager) Resume() error {
+	m.auditCaller("resume", "")
+	return m.resume()
+}
+
+func (m *Manager) resume() error {
+	ctx, t, err := m.beginTransition(true)
+	if t == nil {
+		return err
+	}
+	defer m.endTransition(t)
+
+	m.logger.Warn("leaving maintenance mode")
+	t.results = m.runPhase(ctx, PhaseResume, m.shutdownOrder(m.handledResources()))
+	err = phaseErrors(t.results)
+	m.readiness(true)
+	m.progress("ready")
+	m.mu.Lock()
+	m.inMaintenance = false
+	m.drained = false
+	m.mu.Unlock()
+	return err
+}
+
+// beginTransition registers a change of maintenance mode. It returns a nil
+// transition, and the error to report, if the change is not possible or not
+// needed.
+func (m *Manager) beginTransition(leaving bool) (context.Context, *transition, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	switch {
+	case !m.handling || m.shuttingDown:
+		return nil, nil, ErrNotHandling
+	case m.transition != nil:
+		return nil, nil, ErrMaintenanceBusy
+	case m.inMaintenance != leaving:
+		return nil, nil, nil
+	}
+	reason := "maintenance"
+	if leaving {
+		reason = "resume"
+	}
+	m.transition = &transition{ctx: newHandoffContext(m.phaseContext(reason)), done: make(chan struct{})}
+	m.inMaintenance = true
+	return m.transition.ctx, m.transition, nil
+}
+
+func (m *Manager) endTransition(t *transition) {
+	t.ctx.end(context.Canceled)
+	m.mu.Lock()
+	m.transition = nil
+	m.mu.Unlock()
+	close(t.done)
+}
+
+// stopMaintenance marks the manager as shutting down, so that maintenance mode
+// can no longer change, and takes over a change in progress: it waits for the
+// change to return, bounding it by the deadline of the shutdown drain, and
+// returns the results of its phases.
+func (m *Manager) stopMaintenance(ctx context.Context) []ResourceReport {
+	m.mu.Lock()
+	m.shuttingDown = true
+	t := m.transition
+	m.mu.Unlock()
+	if t == nil {
+		return nil
+	}
+	m.logger.Warn("waiting for maintenance mode change to complete")
+	ctx, cancel := m.drainContext(ctx)
+	defer cancel()
+	stop := t.ctx.follow(ctx)
+	defer stop()
+	<-t.done
+	return t.results
+}
+
+func (m *Manager) logMaintenanceError(action string, change func() error) {
+	if err := change(); err != nil {
+		m.logger.Error(fmt.Sprintf("error on %s maintenance mode: %s", action, err.Error()))
+	}
+}
@@ -0,0 +1,137 @@
+package gracefulshutdown
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMaintenanceEnterAndResume(t *testing.T) {
+	m := NewManager(&testLogger{})
+	res := &drainingResource{fakeResource: fakeResource{name: "server"}}
+	if err := m.EnterMaintenance(); !errors.Is(err, ErrNotHandling) {
+		t.Fatalf("EnterMaintenance before Handle = %v", err)
+	}
+	done := handle(m, res)
+	waitFor(t, "handling", func() bool {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		return m.handling
+	})
+
+	if err := m.EnterMaintenance(); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.EnterMaintenance(); err != nil {
+		t.Fatalf("second EnterMaintenance = %v", err)
+	}
+	if err := m.Resume(); err != nil {
+		t.Fatal(err)
+	}
+	if got := res.called(); !slices.Equal(got, []string{"pause", "drain", "resume"}) {
+		t.Errorf("calls = %v", got)
+	}
+
+	m.Trigger("test")
+	<-done
+	if got := res.called(); !slices.Equal(got, []string{"pause", "drain", "resume", "pause", "drain", "close"}) {
+		t.Errorf("calls after shutdown = %v", got)
+	}
+}
+
+func TestShutdownTakesOverMaintenanceDrain(t *testing.T) {
+	m := NewManager(&testLogger{})
+	res := &drainingResource{fakeResource: fakeResource{name: "server"}, drain: blockUntilDone}
+	done := handle(m, res)
+	waitHandling(t, m)
+
+	entered := make(chan error, 1)
+	go func() { entered <- m.EnterMaintenance() }()
+	waitFor(t, "maintenance drain", func() bool { return slices.Contains(res.called(), "drain") })
+	if err := m.Resume(); !errors.Is(err, ErrMaintenanceBusy) {
+		t.Errorf("Resume during drain = %v", err)
+	}
+
+	m.trigger(shutdownTrigger{reason: "test", deadline: time.Now().Add(50 * time.Millisecond)})
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("shutdown blocked by the maintenance drain")
+	}
+	if err := <-entered; err == nil || !strings.Contains(err.Error(), context.DeadlineExceeded.Error()) {
+		t.Errorf("maintenance drain = %v", err)
+	}
+	// The maintenance drain ends at the shutdown deadline, is reported as
+	// timed out and is not run again.
+	if got := res.called(); !slices.Equal(got, []string{"pause", "drain", "close"}) {
+		t.Errorf("calls = %v", got)
+	}
+	report := m.Report()
+	if len(report.Resources) != 3 || report.Resources[1].Phase != PhaseDrain || !report.Resources[1].TimedOut {
+		t.Errorf("report = %+v", report.Resources)
+	}
+}
+
+func TestShutdownLetsMaintenanceDrainFinish(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		io.WriteString(w, "ok")
+	}))
+	defer srv.Close()
+	transport := NewTransport(nil)
+	m := NewManager(&testLogger{}, WithDrainTimeout(5*time.Second))
+	done := handle(m, transport)
+	waitHandling(t, m)
+
+	response := make(chan error, 1)
+	go func() {
+		resp, err := (&http.Client{Transport: transport}).Get(srv.URL)
+		if err == nil {
+			_, err = io.ReadAll(resp.Body)
+			resp.Body.Close()
+		}
+		response <- err
+	}()
+	waitFor(t, "request in flight", func() bool { return transport.InFlight() == 1 })
+	entered := make(chan error, 1)
+	go func() { entered <- m.EnterMaintenance() }()
+	waitFor(t, "maintenance drain", func() bool {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		return m.transition != nil
+	})
+
+	m.Trigger("test")
+	time.Sleep(50 * time.Millisecond)
+	if n := len(transport.Cancelled()); n != 0 {
+		t.Fatalf("outbound request cancelled when shutdown started: %v", transport.Cancelled())
+	}
+	close(release)
+	if err := <-response; err != nil {
+		t.Errorf("outbound request = %v", err)
+	}
+	<-done
+	if err := <-entered; err != nil {
+		t.Errorf("maintenance drain = %v", err)
+	}
+	report := m.Report()
+	if report.Failed() || len(report.Resources) != 2 || report.Resources[0].Phase != PhaseDrain {
+		t.Errorf("report = %+v", report.Resources)
+	}
+}
+
+func TestMaintenanceSignalErrorsAreLogged(t *testing.T) {
+	logger := &testLogger{}
+	m := NewManager(logger)
+	m.logMaintenanceError("enter", m.enterMaintenance)
+	if !logger.contains("ERROR error on enter maintenance mode: " + ErrNotHandling.Error()) {
+		t.Errorf("error not logged:\n%s", logger)
+	}
+}
@@ -0,0 +1,261 @@
+package gracefulshutdown
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/signal"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+// Manager monitors operating system signals and runs the shutdown sequence
+// with the behaviour configured by its options.
+type Manager struct {
+	logger            Logger
+	trace             *traceConfig
+	setReady          func(bool)
+	drainTimeout      time.Duration
+	maintenanceEnter  os.Signal
+	maintenanceResume os.Signal
+	progressFile      *os.File
+	reportFile        string
+	zeroizers         []Zeroizer
+	deps              depGraph
+	audit             *auditLog
+	unblock           unblockRegistry
+	heartbeats        heartbeatRegistry
+	stallShutdown     bool
+	terminationPath   string
+	triggers          chan shutdownTrigger
+	preemption        *PreemptionConfig
+	failureExitCode   bool
+	summary           bool
+	summaryWriter     io.Writer
+	shutdownLevel     *slog.Level
+	levelVar          *slog.LevelVar
+
+	resources atomic.Pointer[[]Closeable]
+
+	mu            sync.Mutex
+	readyFiles    []*os.File
+	pidFile       string
+	handling      bool
+	shuttingDown  bool
+	inMaintenance bool
+	drained       bool
+	transition    *transition
+	report        Report
+}
+
+// Option configures a Manager.
+type Option func(*Manager)
+
+func NewManager(logger Logger, opts ...Option) *Manager {
+	m := &Manager{logger: logger, triggers: make(chan shutdownTrigger, 1)}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
+}
+
+func (m *Manager) Handle(closeable ...Closeable) <-chan bool {
+	m.do(false, closeable...)
+	terminated := make(chan bool, 1)
+	defer close(terminated)
+	terminated <- true
+	return terminated
+}
+
+// HandleAndTerminate runs the shutdown sequence like Handle and then exits the
+// process with ExitOK, or with Report.ExitCode if WithFailureExitCode is set.
+// A second termination signal received while the sequence runs exits at once
+// with ExitForced.
+func (m *Manager) HandleAndTerminate(closeable ...Closeable) {
+	report := m.do(true, closeable...)
+	if !m.failureExitCode {
+		osExit(ExitOK)
+		return
+	}
+	osExit(report.ExitCode)
+}
+
+// osExit is replaced in tests.
+var osExit = os.Exit
+
+func (m *Manager) do(terminate bool, closeable ...Closeable) Report {
+	logger := m.logger
+	m.mu.Lock()
+	m.resources.Store(&closeable)
+	m.handling = true
+	m.mu.Unlock()
+
+	osSignals := make(chan os.Signal, 1)
+	signals := []os.Signal{syscall.SIGTERM, syscall.SIGQUIT, syscall.SIGQUIT, syscall.SIGINT}
+	if m.maintenanceEnter != nil {
+		signals = append(signals, m.maintenanceEnter, m.maintenanceResume)
+	}
+	signal.Notify(osSignals, signals...)
+	defer signal.Stop(osSignals)
+	stopPolling := m.pollPreemption()
+	var osSignal os.Signal
+	var trigger shutdownTrigger
+	for trigger.reason == "" {
+		select {
+		case s := <-osSignals:
+			switch s {
+			case m.maintenanceEnter:
+				m.auditSignal("maintenance", s)
+				go m.logMaintenanceError("enter", m.enterMaintenance)
+			case m.maintenanceResume:
+				m.auditSignal("resume", s)
+				go m.logMaintenanceError("leave", m.resume)
+			default:
+				logger.Warn(fmt.Sprintf("system call receipt -> %v", s))
+				m.auditSignal("shutdown", s)
+				osSignal = s
+				trigger.reason = "signal: " + s.String()
+			}
+		case trigger = <-m.triggers:
+			logger.Warn(fmt.Sprintf("shutdown triggered -> %s", trigger.reason))
+		}
+	}
+	stopPolling()
+	m.stopHeartbeats()
+	tr := m.startTrace()
+	if terminate {
+		finished := make(chan struct{})
+		defer close(finished)
+		go m.forceExitOnSignal(osSignals, finished, closeable)
+	}
+
+	ctx := m.phaseContext(trigger.reason)
+	if !trigger.deadline.IsZero() {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithDeadline(ctx, trigger.deadline)
+		defer cancel()
+	}
+	handedOver := m.stopMaintenance(ctx)
+
+	m.mu.Lock()
+	inMaintenance, drained := m.inMaintenance, m.drained
+	m.mu.Unlock()
+	m.raiseVerbosity()
+	if m.deps.infer {
+		m.verifyDependencies()
+	}
+	ordered := m.shutdownOrder(closeable)
+	zeroized := false
+	defer func() {
+		if !zeroized {
+			m.zeroize(ctx, ordered)
+		}
+	}()
+	report := Report{Reason: trigger.reason, StartedAt: time.Now(), Resources: handedOver}
+	if !trigger.deadline.IsZero() {
+		report.Budget = max(0, time.Until(trigger.deadline))
+		logger.Warn(fmt.Sprintf("shutdown budget is %v", report.Budget.Round(time.Millisecond)))
+	}
+	if osSignal != nil {
+		report.Signal = osSignal.String()
+	}
+	if !inMaintenance {
+		m.progress("draining")
+		m.unblockAt(PhaseReadiness)
+		m.readiness(false)
+		m.unblockAt(PhasePause)
+		report.Resources = append(report.Resources, m.runPhase(ctx, PhasePause, ordered)...)
+	}
+	if !drained {
+		m.unblockAt(PhaseDrain)
+		report.Resources = append(report.Resources, m.runPhase(ctx, PhaseDrain, ordered)...)
+	}
+	m.progress("closing")
+	m.unblockAt(PhaseClose)
+	logger.Info("closing resources...")
+	report.Resources = append(report.Resources, m.runPhase(ctx, PhaseClose, ordered)...)
+	m.unblockAt(PhaseZeroize)
+	report.Resources = append(report.Resources, m.zeroize(ctx, ordered)...)
+	zeroized = true
+	report.Duration = time.Since(report.StartedAt)
+	report.Unblocked = m.unblocked()
+	report.ExitCode = report.exitCode()
+	tr.stop(logger, &report)
+	m.writeSummary(report)
+	if report.Failed() {
+		dumpDebug(logger)
+	}
+	logger.Warn("system was terminated by system call")
+	m.progress("stopped")
+	m.removePIDFile()
+	m.writeReport(report)
+	m.writeTerminationMessage(report)
+	m.mu.Lock()
+	m.report = report
+	m.mu.Unlock()
+	return report
+}
+
+// forceExitOnSignal exits the process with ExitForced if a second termination
+// signal arrives before finished is closed, after dumping buffered debug
+// records and wiping secrets.
+func (m *Manager) forceExitOnSignal(osSignals <-chan os.Signal, finished <-chan struct{}, resources []Closeable) {
+	for {
+		select {
+		case <-finished:
+			return
+		case s := <-osSignals:
+			if s == m.maintenanceEnter || s == m.maintenanceResume {
+				continue
+			}
+			m.logger.Error(fmt.Sprintf("system call receipt during shutdown -> %v, forcing exit", s))
+			m.auditSignal("forced exit", s)
+			dumpDebug(m.logger)
+			m.zeroize(m.phaseContext("forced exit"), inRegistrationOrder(resources))
+			osExit(ExitForced)
+			return
+		}
+	}
+}
+
+// Trigger starts the shutdown sequence as if a termination signal had been
+// received, recording reason in the Report. Only the first trigger is kept.
+func (m *Manager) Trigger(reason string) {
+	if reason == "" {
+		reason = "programmatic"
+	}
+	m.auditCaller("shutdown", reason)
+	m.trigger(shutdownTrigger{reason: reason})
+}
+
+// shutdownTrigger is a request to start the shutdown sequence. A non-zero
+// deadline bounds every phase of the sequence.
+type shutdownTrigger struct {
+	reason   string
+	deadline time.Time
+}
+
+func (m *Manager) trigger(t shutdownTrigger) {
+	select {
+	case m.triggers <- t:
+	default:
+	}
+}
+
+// Report returns the outcome of the last completed shutdown sequence.
+func (m *Manager) Report() Report {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.report
+}
+
+func (m *Manager) handledResources() []Closeable {
+	if r := m.resources.Load(); r != nil {
+		return *r
+	}
+	return nil
+}
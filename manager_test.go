@@ -0,0 +1,54 @@
+package gracefulshutdown
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestManagerClosesResourcesInOrder(t *testing.T) {
+	logger := &testLogger{}
+	db, cache := &fakeResource{name: "db"}, failing("cache")
+	report := shutdown(t, NewManager(logger), db, cache)
+
+	if report.Reason != "test" {
+		t.Errorf("reason = %q", report.Reason)
+	}
+	var names []string
+	for _, res := range report.Resources {
+		names = append(names, res.Name)
+	}
+	if !slices.Equal(names, []string{"db", "cache"}) {
+		t.Errorf("closed %v", names)
+	}
+	if report.Resources[0].Error != "" || report.Resources[1].Error == "" {
+		t.Errorf("errors not reported per resource: %+v", report.Resources)
+	}
+	if !logger.contains("ERROR error on close resource: cache: boom") {
+		t.Errorf("close error not logged:\n%s", logger)
+	}
+}
+
+func TestManagerCallsDuringShutdownDoNotBlock(t *testing.T) {
+	m := NewManager(&testLogger{})
+	var maintenanceErr, resumeErr error
+	res := &drainingResource{fakeResource: fakeResource{name: "admin"}}
+	res.drain = func(ctx context.Context) error {
+		// An admin endpoint served by a draining server calls back into
+		// the manager.
+		maintenanceErr = m.EnterMaintenance()
+		resumeErr = m.Resume()
+		m.Report()
+		m.States()
+		return m.Ready()
+	}
+	report := shutdown(t, m, res)
+
+	if !errors.Is(maintenanceErr, ErrNotHandling) || !errors.Is(resumeErr, ErrNotHandling) {
+		t.Errorf("maintenance during shutdown = %v, %v", maintenanceErr, resumeErr)
+	}
+	if report.Failed() {
+		t.Errorf("report = %+v", report.Resources)
+	}
+}
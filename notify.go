@@ -0,0 +1,51 @@
+package gracefulshutdown
+
+import (
+	"errors"
+	"fmt"
+	"os"
+)
+
+// WithNotifyFD enables readiness notification for supervisors such as s6 or
+// runit. Ready writes a newline to readyFD and closes it, following the s6
+// notification-fd protocol. During shutdown, one line per state is written to
+// progressFD: "draining" when the instance leaves rotation, "closing" when
+// resources start to close and "stopped" when the sequence is complete; "ready"
+// is written when maintenance mode ends. A negative fd disables that channel.
+func WithNotifyFD(readyFD, progressFD int) Option {
+	return func(m *Manager) {
+		if readyFD >= 0 {
+			m.readyFiles = append(m.readyFiles, os.NewFile(uintptr(readyFD), "readiness-fd"))
+		}
+		if progressFD >= 0 {
+			m.progressFile = os.NewFile(uintptr(progressFD), "progress-fd")
+		}
+	}
+}
+
+// Ready reports startup completion on the readiness fd, and to the parent
+// process of a daemon started by Daemonize. It is a no-op if neither is
+// configured or readiness was already reported.
+func (m *Manager) Ready() error {
+	m.mu.Lock()
+	files := m.readyFiles
+	m.readyFiles = nil
+	m.mu.Unlock()
+	var errs []error
+	for _, f := range files {
+		if _, err := f.Write([]byte("\n")); err != nil {
+			errs = append(errs, fmt.Errorf("gracefulshutdown: notify readiness on %s: %w", f.Name(), err))
+		}
+		f.Close()
+	}
+	return errors.Join(errs...)
+}
+
+func (m *Manager) progress(state string) {
+	if m.progressFile == nil {
+		return
+	}
+	if _, err := fmt.Fprintln(m.progressFile, state); err != nil {
+		m.logger.Error(fmt.Sprintf("error on notify progress: %s", err.Error()))
+	}
+}
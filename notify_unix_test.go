@@ -0,0 +1,58 @@
+//go:build unix
+
+package gracefulshutdown
+
+import (
+	"io"
+	"os"
+	"syscall"
+	"testing"
+)
+
+// pipe returns the read end of a pipe and the raw fd of its write end, which
+// the manager takes ownership of.
+func pipe(t *testing.T) (*os.File, int) {
+	t.Helper()
+	var fds [2]int
+	if err := syscall.Pipe(fds[:]); err != nil {
+		t.Fatal(err)
+	}
+	r := os.NewFile(uintptr(fds[0]), "pipe")
+	t.Cleanup(func() { r.Close() })
+	return r, fds[1]
+}
+
+func TestNotifyFDReadyAndProgress(t *testing.T) {
+	readyR, readyFD := pipe(t)
+	progressR, progressFD := pipe(t)
+	m := NewManager(&testLogger{}, WithNotifyFD(readyFD, progressFD))
+
+	if err := m.Ready(); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Ready(); err != nil {
+		t.Fatalf("second Ready = %v", err)
+	}
+	// Ready closes the fd, so the reader sees EOF after the newline.
+	if got, err := io.ReadAll(readyR); err != nil || string(got) != "\n" {
+		t.Errorf("readiness fd = %q, %v", got, err)
+	}
+
+	shutdown(t, m, &fakeResource{name: "db"})
+	m.progressFile.Close()
+	got, err := io.ReadAll(progressR)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "draining\nclosing\nstopped\n"; string(got) != want {
+		t.Errorf("progress fd = %q, want %q", got, want)
+	}
+}
+
+func TestNotifyFDDisabled(t *testing.T) {
+	m := NewManager(&testLogger{}, WithNotifyFD(-1, -1))
+	if err := m.Ready(); err != nil {
+		t.Errorf("Ready without fd = %v", err)
+	}
+	shutdown(t, m, &fakeResource{name: "db"})
+}
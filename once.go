@@ -0,0 +1,103 @@
+package gracefulshutdown
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+)
+
+// ResourceState is the lifecycle state of a resource wrapped by CloseOnce.
+type ResourceState int32
+
+const (
+	StateOpen ResourceState = iota
+	StateClosing
+	StateClosed
+	StateFailed
+)
+
+func (s ResourceState) String() string {
+	switch s {
+	case StateOpen:
+		return "open"
+	case StateClosing:
+		return "closing"
+	case StateClosed:
+		return "closed"
+	case StateFailed:
+		return "failed"
+	}
+	return fmt.Sprintf("ResourceState(%d)", int32(s))
+}
+
+// OnceCloser makes Close idempotent and safe for concurrent use: the wrapped
+// resource is closed once and every caller gets the result of that call.
+type OnceCloser struct {
+	closeable Closeable
+	once      sync.Once
+	state     atomic.Int32
+	err       error
+}
+
+// CloseOnce wraps c so that it can be closed both by application code and by
+// the manager without double-close errors.
+func CloseOnce(c Closeable) *OnceCloser {
+	return &OnceCloser{closeable: c}
+}
+
+func (o *OnceCloser) Close() error {
+	return o.CloseContext(context.Background())
+}
+
+// CloseContext closes the wrapped resource with ctx if it is a
+// ContextCloseable, or with Close otherwise.
+func (o *OnceCloser) CloseContext(ctx context.Context) error {
+	o.once.Do(func() {
+		o.state.Store(int32(StateClosing))
+		o.err = closeContext(ctx, o.closeable)
+		if o.err != nil {
+			o.state.Store(int32(StateFailed))
+			return
+		}
+		o.state.Store(int32(StateClosed))
+	})
+	return o.err
+}
+
+func (o *OnceCloser) State() ResourceState {
+	return ResourceState(o.state.Load())
+}
+
+func (o *OnceCloser) Name() string {
+	return resourceName(o.closeable)
+}
+
+// Unwrap returns the wrapped resource.
+func (o *OnceCloser) Unwrap() Closeable {
+	return o.closeable
+}
+
+type stater interface {
+	State() ResourceState
+}
+
+// ResourceStatus is the state of a resource handled by a Manager.
+type ResourceStatus struct {
+	Index int
+	Name  string
+	State ResourceState
+}
+
+// States returns the state of every handled resource that reports one, such
+// as those wrapped by CloseOnce. It does not wait for a running shutdown
+// sequence, so it can back admin endpoints.
+func (m *Manager) States() []ResourceStatus {
+	var states []ResourceStatus
+	for i, c := range m.handledResources() {
+		if s, ok := as[stater](c); ok {
+			states = append(states, ResourceStatus{Index: i, Name: resourceName(c), State: s.State()})
+		}
+	}
+	return states
+}
@@ -0,0 +1,78 @@
+package gracefulshutdown
+
+import (
+	"errors"
+	"sync"
+	"testing"
+)
+
+func TestCloseOnceClosesOnce(t *testing.T) {
+	res := failing("db")
+	c := CloseOnce(res)
+	if c.State() != StateOpen {
+		t.Errorf("state before close = %v", c.State())
+	}
+
+	var wg sync.WaitGroup
+	errs := make([]error, 8)
+	for i := range errs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = c.Close()
+		}()
+	}
+	wg.Wait()
+
+	if n := len(res.called()); n != 1 {
+		t.Errorf("wrapped Close called %d times", n)
+	}
+	for i, err := range errs {
+		if !errors.Is(err, errBoom) {
+			t.Errorf("caller %d got %v", i, err)
+		}
+	}
+	if c.State() != StateFailed {
+		t.Errorf("state after failed close = %v", c.State())
+	}
+	if c.Name() != "db" || c.Unwrap() != Closeable(res) {
+		t.Errorf("name %q, unwrap %v", c.Name(), c.Unwrap())
+	}
+}
+
+func TestCloseOnceState(t *testing.T) {
+	res := &fakeResource{name: "db", block: make(chan struct{})}
+	c := CloseOnce(res)
+	done := make(chan error, 1)
+	go func() { done <- c.Close() }()
+	waitFor(t, "closing state", func() bool { return c.State() == StateClosing })
+	close(res.block)
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	if c.State() != StateClosed {
+		t.Errorf("state = %v", c.State())
+	}
+	if s := ResourceState(9).String(); s != "ResourceState(9)" {
+		t.Errorf("unknown state = %q", s)
+	}
+}
+
+func TestManagerStatesAndAlreadyClosed(t *testing.T) {
+	logger := &testLogger{}
+	m := NewManager(logger)
+	db := CloseOnce(&fakeResource{name: "db"})
+	plain := &fakeResource{name: "plain"}
+	if err := db.Close(); err != nil {
+		t.Fatal(err)
+	}
+	shutdown(t, m, plain, db)
+
+	states := m.States()
+	if len(states) != 1 || states[0] != (ResourceStatus{Index: 1, Name: "db", State: StateClosed}) {
+		t.Errorf("states = %+v", states)
+	}
+	if !logger.contains("resource 1 already closed") {
+		t.Errorf("already closed resource not logged:\n%s", logger)
+	}
+}
@@ -0,0 +1,13 @@
+//go:build linux
+
+package gracefulshutdown
+
+import "syscall"
+
+func peerCredentials(fd uintptr) (uid, pid int, err error) {
+	cred, err := syscall.GetsockoptUcred(int(fd), syscall.SOL_SOCKET, syscall.SO_PEERCRED)
+	if err != nil {
+		return 0, 0, err
+	}
+	return int(cred.Uid), int(cred.Pid), nil
+}
@@ -0,0 +1,9 @@
+//go:build !linux
+
+package gracefulshutdown
+
+import "errors"
+
+func peerCredentials(uintptr) (uid, pid int, err error) {
+	return 0, 0, errors.ErrUnsupported
+}
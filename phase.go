@@ -0,0 +1,193 @@
+package gracefulshutdown
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/pprof"
+	"time"
+)
+
+// Phase identifies a step of the shutdown sequence.
+type Phase string
+
+const (
+	PhaseReadiness Phase = "readiness"
+	PhasePause     Phase = "pause"
+	PhaseDrain     Phase = "drain"
+	PhaseClose     Phase = "close"
+	PhaseZeroize   Phase = "zeroize"
+	PhaseResume    Phase = "resume"
+)
+
+// Pausable resources stop accepting new work in the pause phase.
+type Pausable interface {
+	Pause() error
+}
+
+// Drainable resources finish their in-flight work in the drain phase, giving
+// up when ctx is done.
+type Drainable interface {
+	Drain(ctx context.Context) error
+}
+
+// Resumable resources accept new work again when maintenance mode ends.
+type Resumable interface {
+	Resume() error
+}
+
+var phaseGerunds = map[Phase]string{
+	PhasePause:   "pausing",
+	PhaseDrain:   "draining",
+	PhaseClose:   "closing",
+	PhaseZeroize: "zeroizing",
+	PhaseResume:  "resuming",
+}
+
+// WithReadiness registers a function that is called with false when the
+// readiness phase takes the instance out of rotation and with true when
+// maintenance mode ends.
+func WithReadiness(setReady func(ready bool)) Option {
+	return func(m *Manager) {
+		m.setReady = setReady
+	}
+}
+
+// WithDrainTimeout bounds the time given to Drainable resources.
+func WithDrainTimeout(timeout time.Duration) Option {
+	return func(m *Manager) {
+		m.drainTimeout = timeout
+	}
+}
+
+func (m *Manager) readiness(ready bool) {
+	if m.setReady == nil {
+		return
+	}
+	m.logger.Info(fmt.Sprintf("setting readiness to %t", ready))
+	m.setReady(ready)
+}
+
+// runPhase calls the method matching phase on every resource that implements
+// it, in the given order, with a context derived from ctx.
+func (m *Manager) runPhase(ctx context.Context, phase Phase, resources []handled) []ResourceReport {
+	if phase == PhaseDrain {
+		var cancel context.CancelFunc
+		ctx, cancel = m.drainContext(ctx)
+		defer cancel()
+	}
+	var targets []phaseTarget
+	for _, h := range resources {
+		if call := phaseCall(phase, h.resource); call != nil {
+			targets = append(targets, phaseTarget{index: h.index, resource: h.resource, call: call})
+		}
+	}
+	return m.runTargets(ctx, phase, targets)
+}
+
+// drainContext bounds ctx by the drain timeout, if any.
+func (m *Manager) drainContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if m.drainTimeout > 0 {
+		return context.WithTimeout(ctx, m.drainTimeout)
+	}
+	return context.WithCancel(ctx)
+}
+
+type reasonKey struct{}
+
+// phaseContext returns the base context passed to resources, carrying the
+// manager logger and the reason the phases run for.
+func (m *Manager) phaseContext(reason string) context.Context {
+	ctx := context.WithValue(context.Background(), loggerKey{}, m.logger)
+	return context.WithValue(ctx, reasonKey{}, reason)
+}
+
+type phaseTarget struct {
+	index    int
+	resource any
+	call     func(ctx context.Context) error
+}
+
+// runTargets calls every target in order under pprof labels naming the
+// resource, the phase and the shutdown reason, so that profiles and goroutine
+// dumps taken during a slow shutdown attribute work to resources.
+func (m *Manager) runTargets(ctx context.Context, phase Phase, targets []phaseTarget) []ResourceReport {
+	var results []ResourceReport
+	for _, t := range targets {
+		if !m.summary {
+			if results == nil && phase != PhaseClose {
+				m.logger.Info(fmt.Sprintf("%s resources...", phaseGerunds[phase]))
+			}
+			if s, ok := as[stater](t.resource); ok && phase == PhaseClose && s.State() != StateOpen {
+				m.logger.Info(fmt.Sprintf("resource %d already %s", t.index, s.State()))
+			}
+			m.logger.Info(fmt.Sprintf("trying to %s resource %d", phase, t.index))
+		}
+		res := ResourceReport{Index: t.index, Name: resourceName(t.resource), Phase: phase}
+		start := time.Now()
+		var err error
+		reason, _ := ctx.Value(reasonKey{}).(string)
+		labels := pprof.Labels("resource", res.Name, "phase", string(phase), "reason", reason)
+		pprof.Do(ctx, labels, func(ctx context.Context) {
+			err = t.call(ctx)
+		})
+		if err != nil {
+			m.logger.Error(fmt.Sprintf("error on %s resource: %s", phase, err.Error()))
+			res.Error = err.Error()
+			res.TimedOut = errors.Is(err, context.DeadlineExceeded)
+		}
+		res.Duration = time.Since(start)
+		results = append(results, res)
+	}
+	return results
+}
+
+func phaseCall(phase Phase, c Closeable) func(context.Context) error {
+	switch phase {
+	case PhasePause:
+		if p, ok := as[Pausable](c); ok {
+			return func(context.Context) error { return p.Pause() }
+		}
+	case PhaseDrain:
+		if d, ok := as[Drainable](c); ok {
+			return d.Drain
+		}
+	case PhaseResume:
+		if r, ok := as[Resumable](c); ok {
+			return func(context.Context) error { return r.Resume() }
+		}
+	case PhaseClose:
+		if cc, ok := c.(ContextCloseable); ok {
+			return cc.CloseContext
+		}
+		return func(context.Context) error { return c.Close() }
+	}
+	return nil
+}
+
+// as returns the first resource in the Unwrap chain of c that implements T, so
+// that wrappers such as CloseOnce and Track keep the optional behaviour of the
+// resources they wrap.
+func as[T any](c any) (T, bool) {
+	for {
+		if t, ok := c.(T); ok {
+			return t, true
+		}
+		u, ok := c.(interface{ Unwrap() Closeable })
+		if !ok {
+			var zero T
+			return zero, false
+		}
+		c = u.Unwrap()
+	}
+}
+
+func phaseErrors(results []ResourceReport) error {
+	var errs []error
+	for _, res := range results {
+		if res.Error != "" {
+			errs = append(errs, fmt.Errorf("%s resource %d: %s", res.Phase, res.Index, res.Error))
+		}
+	}
+	return errors.Join(errs...)
+}
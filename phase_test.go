@@ -0,0 +1,65 @@
+package gracefulshutdown
+
+import (
+	"context"
+	"runtime/pprof"
+	"slices"
+	"testing"
+)
+
+// labelRecorder records the pprof labels of the context of every call.
+type labelRecorder struct {
+	fakeResource
+	labels []map[string]string
+}
+
+func (r *labelRecorder) record(ctx context.Context) {
+	labels := map[string]string{}
+	pprof.ForLabels(ctx, func(k, v string) bool {
+		labels[k] = v
+		return true
+	})
+	r.labels = append(r.labels, labels)
+}
+
+func (r *labelRecorder) Drain(ctx context.Context) error {
+	r.record(ctx)
+	return nil
+}
+
+func (r *labelRecorder) CloseContext(ctx context.Context) error {
+	r.record(ctx)
+	return nil
+}
+
+func TestPhasesRunUnderPprofLabels(t *testing.T) {
+	res := &labelRecorder{fakeResource: fakeResource{name: "orders-db"}}
+	shutdown(t, NewManager(&testLogger{}), res)
+
+	if len(res.labels) != 2 {
+		t.Fatalf("labels = %v", res.labels)
+	}
+	for i, phase := range []Phase{PhaseDrain, PhaseClose} {
+		want := map[string]string{"resource": "orders-db", "phase": string(phase), "reason": "test"}
+		for k, v := range want {
+			if res.labels[i][k] != v {
+				t.Errorf("%s labels = %v, want %v", phase, res.labels[i], want)
+				break
+			}
+		}
+	}
+}
+
+func TestPhaseOrder(t *testing.T) {
+	var ready []bool
+	m := NewManager(&testLogger{}, WithReadiness(func(r bool) { ready = append(ready, r) }))
+	res := &drainingResource{fakeResource: fakeResource{name: "server"}}
+	shutdown(t, m, res)
+
+	if got := res.called(); !slices.Equal(got, []string{"pause", "drain", "close"}) {
+		t.Errorf("calls = %v", got)
+	}
+	if !slices.Equal(ready, []bool{false}) {
+		t.Errorf("readiness = %v", ready)
+	}
+}
@@ -0,0 +1,134 @@
+package gracefulshutdown
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// PreemptionConfig configures polling of an instance metadata endpoint for a
+// spot or preemption termination notice.
+type PreemptionConfig struct {
+	// URL is polled with GET. A 404 response or a body of "FALSE" means no
+	// notice. A notice is a JSON object with a "time" field in RFC 3339
+	// format, a bare RFC 3339 time, or "TRUE" for notices without a time.
+	URL string
+	// Header is added to every request, such as "Metadata-Flavor: Google".
+	Header http.Header
+	// Interval between polls. Defaults to 5 seconds.
+	Interval time.Duration
+	// Margin is kept free before the notice time, so the shutdown budget is
+	// the time left until the notice time minus Margin.
+	Margin time.Duration
+	// DefaultBudget is the budget for notices without a time. Defaults to 30
+	// seconds.
+	DefaultBudget time.Duration
+	// Client sends the requests. Defaults to a client with a 2 second timeout.
+	Client *http.Client
+}
+
+// WithPreemptionNotice polls cfg.URL while resources are handled and starts
+// the shutdown sequence when a termination notice appears, with every phase
+// bounded by the budget derived from the notice.
+func WithPreemptionNotice(cfg PreemptionConfig) Option {
+	if cfg.Interval <= 0 {
+		cfg.Interval = 5 * time.Second
+	}
+	if cfg.DefaultBudget <= 0 {
+		cfg.DefaultBudget = 30 * time.Second
+	}
+	if cfg.Client == nil {
+		cfg.Client = &http.Client{Timeout: 2 * time.Second}
+	}
+	return func(m *Manager) {
+		m.preemption = &cfg
+	}
+}
+
+// pollPreemption starts polling the preemption endpoint, if configured, and
+// returns a function that stops it.
+func (m *Manager) pollPreemption() func() {
+	if m.preemption == nil {
+		return func() {}
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		ticker := time.NewTicker(m.preemption.Interval)
+		defer ticker.Stop()
+		for {
+			notice, ok, err := m.preemption.poll(ctx)
+			switch {
+			case err != nil && ctx.Err() == nil:
+				m.logger.Warn(fmt.Sprintf("error on poll preemption notice: %s", err.Error()))
+			case ok:
+				deadline := notice.Add(-m.preemption.Margin)
+				reason := fmt.Sprintf("preemption notice: terminate at %s", notice.Format(time.RFC3339))
+				m.writeAudit(AuditEntry{Action: "shutdown", Source: "preemption", Reason: reason})
+				m.trigger(shutdownTrigger{reason: reason, deadline: deadline})
+				return
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	return cancel
+}
+
+// poll returns the termination time announced by the endpoint, if any.
+func (c *PreemptionConfig) poll(ctx context.Context) (time.Time, bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.URL, nil)
+	if err != nil {
+		return time.Time{}, false, err
+	}
+	for k, v := range c.Header {
+		req.Header[k] = v
+	}
+	resp, err := c.Client.Do(req)
+	if err != nil {
+		return time.Time{}, false, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return time.Time{}, false, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return time.Time{}, false, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	b, err := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	if err != nil {
+		return time.Time{}, false, err
+	}
+	return c.parse(strings.TrimSpace(string(b)))
+}
+
+func (c *PreemptionConfig) parse(body string) (time.Time, bool, error) {
+	switch {
+	case body == "" || strings.EqualFold(body, "false"):
+		return time.Time{}, false, nil
+	case strings.EqualFold(body, "true"):
+		return time.Now().Add(c.DefaultBudget), true, nil
+	case strings.HasPrefix(body, "{"):
+		var notice struct {
+			Time string `json:"time"`
+		}
+		if err := json.Unmarshal([]byte(body), &notice); err != nil {
+			return time.Time{}, false, fmt.Errorf("decode preemption notice: %w", err)
+		}
+		if notice.Time == "" {
+			return time.Now().Add(c.DefaultBudget), true, nil
+		}
+		body = notice.Time
+	}
+	t, err := time.Parse(time.RFC3339, body)
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("parse preemption notice: %w", err)
+	}
+	return t, true, nil
+}
@@ -0,0 +1,98 @@
+package gracefulshutdown
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// metadataServer answers every poll with status and body, counting polls.
+func metadataServer(t *testing.T, status int, body string) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var polls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		polls.Add(1)
+		if r.Header.Get("Metadata-Flavor") != "Google" {
+			t.Errorf("header not sent: %v", r.Header)
+		}
+		w.WriteHeader(status)
+		fmt.Fprint(w, body)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &polls
+}
+
+func preemptionManager(logger Logger, url string, margin time.Duration) *Manager {
+	return NewManager(logger, WithPreemptionNotice(PreemptionConfig{
+		URL:      url,
+		Header:   http.Header{"Metadata-Flavor": {"Google"}},
+		Interval: 5 * time.Millisecond,
+		Margin:   margin,
+	}))
+}
+
+// assertNotTriggered polls until the server has answered a few times and
+// checks that no shutdown was triggered.
+func assertNotTriggered(t *testing.T, m *Manager, polls *atomic.Int32) {
+	t.Helper()
+	stop := m.pollPreemption()
+	waitFor(t, "polls", func() bool { return polls.Load() >= 3 })
+	stop()
+	select {
+	case tr := <-m.triggers:
+		t.Errorf("shutdown triggered: %+v", tr)
+	default:
+	}
+}
+
+func TestPreemptionNotFoundIsNoNotice(t *testing.T) {
+	srv, polls := metadataServer(t, http.StatusNotFound, "not found")
+	logger := &testLogger{}
+	assertNotTriggered(t, preemptionManager(logger, srv.URL, 0), polls)
+	if logger.contains("WARN") {
+		t.Errorf("404 logged as an error:\n%s", logger)
+	}
+}
+
+func TestPreemptionErrorStatusIsLogged(t *testing.T) {
+	srv, polls := metadataServer(t, http.StatusInternalServerError, "")
+	logger := &testLogger{}
+	assertNotTriggered(t, preemptionManager(logger, srv.URL, 0), polls)
+	if !logger.contains("WARN error on poll preemption notice: unexpected status 500 Internal Server Error") {
+		t.Errorf("status not logged:\n%s", logger)
+	}
+}
+
+func TestPreemptionNoticeWithoutTimeUsesDefaultBudget(t *testing.T) {
+	srv, _ := metadataServer(t, http.StatusOK, "TRUE\n")
+	m := preemptionManager(&testLogger{}, srv.URL, 0)
+	<-handle(m)
+
+	report := m.Report()
+	if !strings.HasPrefix(report.Reason, "preemption notice: terminate at ") {
+		t.Errorf("reason = %q", report.Reason)
+	}
+	if report.Budget <= 29*time.Second || report.Budget > 30*time.Second {
+		t.Errorf("budget = %v, want about the 30s default", report.Budget)
+	}
+}
+
+func TestPreemptionNoticeTimeSetsBudget(t *testing.T) {
+	notice := time.Now().Add(time.Minute).UTC().Format(time.RFC3339)
+	srv, _ := metadataServer(t, http.StatusOK, `{"action":"terminate","time":"`+notice+`"}`)
+	m := preemptionManager(&testLogger{}, srv.URL, 10*time.Second)
+	<-handle(m)
+
+	report := m.Report()
+	if report.Reason != "preemption notice: terminate at "+notice {
+		t.Errorf("reason = %q", report.Reason)
+	}
+	// The notice has second precision.
+	if report.Budget <= 48*time.Second || report.Budget > 50*time.Second {
+		t.Errorf("budget = %v, want about 1m minus the 10s margin", report.Budget)
+	}
+}
@@ -0,0 +1,142 @@
+package gracefulshutdown
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Exit statuses set on Report.ExitCode. HandleAndTerminate exits with ExitOK
+// unless WithFailureExitCode is set.
+const (
+	// ExitOK is the status of a sequence in which every resource succeeded.
+	ExitOK = 0
+	// ExitFailed is the status of a sequence in which some resource failed or
+	// timed out, as reported by Report.Failed.
+	ExitFailed = 1
+	// ExitForced is the status HandleAndTerminate exits with when a second
+	// termination signal arrives before the sequence completes.
+	ExitForced = 2
+)
+
+// Report describes the outcome of a shutdown sequence.
+type Report struct {
+	Signal    string           `json:"signal"`
+	Reason    string           `json:"reason"`
+	StartedAt time.Time        `json:"started_at"`
+	Duration  time.Duration    `json:"duration"`
+	Budget    time.Duration    `json:"budget,omitempty"`
+	ExitCode  int              `json:"exit_code"`
+	Resources []ResourceReport `json:"resources"`
+	Unblocked map[string]int   `json:"unblocked,omitempty"`
+	TraceFile string           `json:"trace_file,omitempty"`
+}
+
+// ResourceReport describes the outcome of a single resource in one phase.
+type ResourceReport struct {
+	Index    int           `json:"index"`
+	Name     string        `json:"name"`
+	Phase    Phase         `json:"phase"`
+	Duration time.Duration `json:"duration"`
+	Error    string        `json:"error,omitempty"`
+	TimedOut bool          `json:"timed_out,omitempty"`
+}
+
+// exitCode returns the exit status matching the outcome of the sequence.
+func (r Report) exitCode() int {
+	if r.Failed() {
+		return ExitFailed
+	}
+	return ExitOK
+}
+
+// Failed reports whether any resource failed during the shutdown sequence.
+func (r Report) Failed() bool {
+	for _, res := range r.Resources {
+		if res.Error != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// WithFailureExitCode makes HandleAndTerminate exit with Report.ExitCode, so
+// that a sequence in which some resource failed or timed out exits with
+// ExitFailed instead of ExitOK.
+func WithFailureExitCode() Option {
+	return func(m *Manager) {
+		m.failureExitCode = true
+	}
+}
+
+// WithReportFile writes the Report as JSON to path when the shutdown sequence
+// completes.
+func WithReportFile(path string) Option {
+	return func(m *Manager) {
+		m.reportFile = path
+	}
+}
+
+// ReadReport reads a Report written by WithReportFile.
+func ReadReport(path string) (Report, error) {
+	var r Report
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return r, err
+	}
+	if err := json.Unmarshal(b, &r); err != nil {
+		return r, fmt.Errorf("gracefulshutdown: decode report %s: %w", path, err)
+	}
+	return r, nil
+}
+
+func (m *Manager) writeReport(report Report) {
+	if m.reportFile == "" {
+		return
+	}
+	b, err := json.Marshal(report)
+	if err == nil {
+		err = writeFileAtomic(m.reportFile, append(b, '\n'))
+	}
+	if err != nil {
+		m.logger.Error(fmt.Sprintf("error on write shutdown report: %s", err.Error()))
+	}
+}
+
+// writeFileAtomic writes b to a temporary file next to path and renames it
+// into place, so that readers never see a partial file if the process is
+// killed while writing.
+func writeFileAtomic(path string, b []byte) error {
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(b)
+	if err == nil {
+		err = f.Chmod(0o644)
+	}
+	if err == nil {
+		err = f.Sync()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), path)
+	}
+	if err != nil {
+		os.Remove(f.Name())
+	}
+	return err
+}
+
+// resourceName returns the name reported for c: the result of its Name method
+// if it has one, or its type otherwise.
+func resourceName(c any) string {
+	if n, ok := c.(interface{ Name() string }); ok {
+		return n.Name()
+	}
+	return fmt.Sprintf("%T", c)
+}
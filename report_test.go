@@ -0,0 +1,70 @@
+package gracefulshutdown
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReportExitCode(t *testing.T) {
+	if code := shutdown(t, NewManager(&testLogger{}), &fakeResource{name: "db"}).ExitCode; code != ExitOK {
+		t.Errorf("clean shutdown exit code = %d, want %d", code, ExitOK)
+	}
+	if code := shutdown(t, NewManager(&testLogger{}), failing("db")).ExitCode; code != ExitFailed {
+		t.Errorf("failed shutdown exit code = %d, want %d", code, ExitFailed)
+	}
+}
+
+func TestHandleAndTerminateExitCode(t *testing.T) {
+	exited := make(chan int, 1)
+	osExit = func(code int) { exited <- code }
+	t.Cleanup(func() { osExit = os.Exit })
+
+	m := NewManager(&testLogger{})
+	m.Trigger("test")
+	m.HandleAndTerminate(failing("db"))
+	if code := <-exited; code != ExitOK {
+		t.Errorf("default exit code = %d, want %d", code, ExitOK)
+	}
+	if code := m.Report().ExitCode; code != ExitFailed {
+		t.Errorf("report exit code = %d, want %d", code, ExitFailed)
+	}
+
+	m = NewManager(&testLogger{}, WithFailureExitCode())
+	m.Trigger("test")
+	m.HandleAndTerminate(failing("db"))
+	if code := <-exited; code != ExitFailed {
+		t.Errorf("exit code with WithFailureExitCode = %d, want %d", code, ExitFailed)
+	}
+}
+
+func TestReportFileRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "report.json")
+	report := shutdown(t, NewManager(&testLogger{}, WithReportFile(path)), failing("db"))
+
+	got, err := ReadReport(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Reason != report.Reason || got.ExitCode != ExitFailed || len(got.Resources) != 1 {
+		t.Errorf("read %+v, wrote %+v", got, report)
+	}
+	if got.Resources[0].Name != "db" || got.Resources[0].Error != "db: boom" {
+		t.Errorf("resource = %+v", got.Resources[0])
+	}
+	if entries, _ := os.ReadDir(filepath.Dir(path)); len(entries) != 1 {
+		t.Errorf("temporary files left next to the report: %v", entries)
+	}
+}
+
+func TestReadReportErrors(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := ReadReport(filepath.Join(dir, "missing.json")); err == nil {
+		t.Error("missing report read without error")
+	}
+	path := filepath.Join(dir, "invalid.json")
+	os.WriteFile(path, []byte("{"), 0o644)
+	if _, err := ReadReport(path); err == nil {
+		t.Error("invalid report read without error")
+	}
+}
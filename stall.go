@@ -0,0 +1,157 @@
+package gracefulshutdown
+
+import (
+	"bytes"
+	"fmt"
+	"runtime"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Heartbeat watches a managed goroutine, which must call Beat at least once per
+// interval. When a beat is missed, the stack of the goroutine is logged and,
+// with WithStallShutdown, the shutdown sequence is triggered with reason
+// "stalled: <name>".
+// Watchers stop when the shutdown sequence starts, since goroutines are
+// expected to stop beating then.
+type Heartbeat struct {
+	name      string
+	interval  time.Duration
+	m         *Manager
+	last      atomic.Int64
+	goroutine atomic.Int64
+	stop      chan struct{}
+	stopOnce  sync.Once
+}
+
+// defaultHeartbeatInterval is used for non-positive intervals.
+const defaultHeartbeatInterval = 10 * time.Second
+
+type heartbeatRegistry struct {
+	mu       sync.Mutex
+	watching map[*Heartbeat]struct{}
+	stopped  bool
+}
+
+// WithStallShutdown triggers the shutdown sequence when a Heartbeat misses its
+// interval, so that the orchestrator replaces the instance.
+func WithStallShutdown() Option {
+	return func(m *Manager) {
+		m.stallShutdown = true
+	}
+}
+
+// Heartbeat starts watching a goroutine identified by name. The first call to
+// Beat identifies the goroutine whose stack is logged on a stall. A
+// non-positive interval defaults to 10 seconds. Once the shutdown sequence has
+// started, the returned Heartbeat is not watched.
+func (m *Manager) Heartbeat(name string, interval time.Duration) *Heartbeat {
+	if interval <= 0 {
+		interval = defaultHeartbeatInterval
+	}
+	h := &Heartbeat{name: name, interval: interval, m: m, stop: make(chan struct{})}
+	h.last.Store(time.Now().UnixNano())
+	r := &m.heartbeats
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.stopped {
+		h.halt()
+		return h
+	}
+	if r.watching == nil {
+		r.watching = map[*Heartbeat]struct{}{}
+	}
+	r.watching[h] = struct{}{}
+	go h.watch()
+	return h
+}
+
+func (h *Heartbeat) Beat() {
+	h.last.Store(time.Now().UnixNano())
+	if h.goroutine.Load() == 0 {
+		h.goroutine.Store(currentGoroutine())
+	}
+}
+
+// Stop ends the watch, for goroutines that exit normally.
+func (h *Heartbeat) Stop() {
+	h.halt()
+	r := &h.m.heartbeats
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.watching, h)
+}
+
+func (h *Heartbeat) halt() {
+	h.stopOnce.Do(func() { close(h.stop) })
+}
+
+// stopHeartbeats stops every watcher when the shutdown sequence starts.
+func (m *Manager) stopHeartbeats() {
+	r := &m.heartbeats
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.stopped = true
+	for h := range r.watching {
+		h.halt()
+	}
+	r.watching = nil
+}
+
+func (h *Heartbeat) watch() {
+	ticker := time.NewTicker(max(h.interval/2, time.Millisecond))
+	defer ticker.Stop()
+	stalled := false
+	for {
+		select {
+		case <-h.stop:
+			return
+		case <-ticker.C:
+		}
+		since := time.Since(time.Unix(0, h.last.Load()))
+		switch {
+		case since <= h.interval:
+			if stalled {
+				h.m.logger.Warn(fmt.Sprintf("goroutine %s resumed after %v", h.name, since.Round(time.Millisecond)))
+				stalled = false
+			}
+		case !stalled:
+			stalled = true
+			h.m.logger.Error(fmt.Sprintf("goroutine %s stalled: no heartbeat for %v\n%s", h.name, since.Round(time.Millisecond), goroutineStack(h.goroutine.Load())))
+			if h.m.stallShutdown {
+				reason := "stalled: " + h.name
+				h.m.writeAudit(AuditEntry{Action: "shutdown", Source: "stall", Reason: reason})
+				h.m.trigger(shutdownTrigger{reason: reason})
+			}
+		}
+	}
+}
+
+// currentGoroutine returns the id of the calling goroutine, parsed from the
+// header of its stack trace.
+func currentGoroutine() int64 {
+	buf := make([]byte, 64)
+	buf = buf[:runtime.Stack(buf, false)]
+	buf = bytes.TrimPrefix(buf, []byte("goroutine "))
+	id, _ := strconv.ParseInt(string(buf[:bytes.IndexByte(buf, ' ')]), 10, 64)
+	return id
+}
+
+// goroutineStack returns the stack of goroutine id, or of every goroutine if
+// id is unknown or no longer running.
+func goroutineStack(id int64) string {
+	buf := make([]byte, 1<<20)
+	buf = buf[:runtime.Stack(buf, true)]
+	if id == 0 {
+		return string(buf)
+	}
+	header := []byte(fmt.Sprintf("goroutine %d [", id))
+	for _, stack := range bytes.Split(buf, []byte("\n\n")) {
+		if bytes.HasPrefix(stack, header) {
+			return string(stack)
+		}
+	}
+	return string(buf)
+}
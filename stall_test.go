@@ -0,0 +1,66 @@
+package gracefulshutdown
+
+import (
+	"testing"
+	"time"
+)
+
+func TestHeartbeatStallTriggersShutdown(t *testing.T) {
+	logger := &testLogger{}
+	m := NewManager(logger, WithStallShutdown())
+	hb := m.Heartbeat("consumer", 20*time.Millisecond)
+	hb.Beat()
+
+	select {
+	case <-handle(m):
+	case <-time.After(5 * time.Second):
+		t.Fatal("stall did not trigger shutdown")
+	}
+	if r := m.Report().Reason; r != "stalled: consumer" {
+		t.Errorf("reason = %q", r)
+	}
+	if !logger.contains("ERROR goroutine consumer stalled: no heartbeat for") || !logger.contains("TestHeartbeatStallTriggersShutdown") {
+		t.Errorf("stall not logged with the goroutine stack:\n%s", logger)
+	}
+}
+
+func TestHeartbeatStopsAtShutdown(t *testing.T) {
+	logger := &testLogger{}
+	m := NewManager(logger)
+	hb := m.Heartbeat("consumer", 20*time.Millisecond)
+	shutdown(t, m)
+	logger.mu.Lock()
+	logger.lines = nil
+	logger.mu.Unlock()
+
+	select {
+	case <-hb.stop:
+	default:
+		t.Fatal("heartbeat still watched after shutdown started")
+	}
+	late := m.Heartbeat("late", 20*time.Millisecond)
+	select {
+	case <-late.stop:
+	default:
+		t.Error("heartbeat created during shutdown is watched")
+	}
+	time.Sleep(60 * time.Millisecond)
+	if logger.contains("stalled") {
+		t.Errorf("stall reported after shutdown:\n%s", logger)
+	}
+	hb.Stop()
+}
+
+func TestHeartbeatDefaultsInterval(t *testing.T) {
+	m := NewManager(&testLogger{})
+	for _, interval := range []time.Duration{0, -time.Second} {
+		hb := m.Heartbeat("consumer", interval)
+		if hb.interval != defaultHeartbeatInterval {
+			t.Errorf("interval %v became %v", interval, hb.interval)
+		}
+		hb.Stop()
+	}
+	if n := len(m.heartbeats.watching); n != 0 {
+		t.Errorf("%d stopped heartbeats still registered", n)
+	}
+}
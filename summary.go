@@ -0,0 +1,61 @@
+package gracefulshutdown
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// WithSummary replaces the per-resource progress lines with one aligned table
+// written when the sequence completes: the phase, status, duration and error
+// of every resource, plus totals. The table is written to w, or through the
+// Logger one line at a time if w is nil. Errors are still logged as they
+// happen.
+func WithSummary(w io.Writer) Option {
+	return func(m *Manager) {
+		m.summary = true
+		m.summaryWriter = w
+	}
+}
+
+func (m *Manager) writeSummary(report Report) {
+	if !m.summary {
+		return
+	}
+	var buf bytes.Buffer
+	tw := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "#\tRESOURCE\tPHASE\tSTATUS\tDURATION\tERROR")
+	failed, timedOut := 0, 0
+	for _, res := range report.Resources {
+		status := "ok"
+		switch {
+		case res.TimedOut:
+			status = "timeout"
+			timedOut++
+		case res.Error != "":
+			status = "failed"
+			failed++
+		}
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%v\t%s\n", res.Index, res.Name, res.Phase, status, res.Duration.Round(time.Microsecond), res.Error)
+	}
+	tw.Flush()
+	lines := strings.Split(strings.TrimRight(buf.String(), "\n"), "\n")
+	for i := range lines {
+		lines[i] = strings.TrimRight(lines[i], " ")
+	}
+	lines = append(lines, fmt.Sprintf("total: %d calls, %d failed, %d timed out in %v (%s)",
+		len(report.Resources), failed, timedOut, report.Duration.Round(time.Microsecond), report.Reason))
+
+	if m.summaryWriter != nil {
+		if _, err := io.WriteString(m.summaryWriter, strings.Join(lines, "\n")+"\n"); err != nil {
+			m.logger.Error(fmt.Sprintf("error on write shutdown summary: %s", err.Error()))
+		}
+		return
+	}
+	for _, line := range lines {
+		m.logger.Info(line)
+	}
+}
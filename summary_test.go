@@ -0,0 +1,51 @@
+package gracefulshutdown
+
+import (
+	"bytes"
+	"context"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSummaryTable(t *testing.T) {
+	var out bytes.Buffer
+	logger := &testLogger{}
+	m := NewManager(logger, WithSummary(&out), WithDrainTimeout(10*time.Millisecond))
+	slow := &drainingResource{fakeResource: fakeResource{name: "server"}, drain: blockUntilDone}
+	shutdown(t, m, slow, failing("db"))
+
+	lines := strings.Split(strings.TrimRight(out.String(), "\n"), "\n")
+	if len(lines) != 6 {
+		t.Fatalf("summary:\n%s", out.String())
+	}
+	if !regexp.MustCompile(`^#\s+RESOURCE\s+PHASE\s+STATUS\s+DURATION\s+ERROR$`).MatchString(lines[0]) {
+		t.Errorf("header = %q", lines[0])
+	}
+	for i, want := range []string{
+		`^0\s+server\s+pause\s+ok\s+\S+$`,
+		`^0\s+server\s+drain\s+timeout\s+\S+\s+` + regexp.QuoteMeta(context.DeadlineExceeded.Error()) + `$`,
+		`^0\s+server\s+close\s+ok\s+\S+$`,
+		`^1\s+db\s+close\s+failed\s+\S+\s+db: boom$`,
+		`^total: 4 calls, 1 failed, 1 timed out in \S+ \(test\)$`,
+	} {
+		if !regexp.MustCompile(want).MatchString(lines[i+1]) {
+			t.Errorf("line %d = %q, want %s", i+1, lines[i+1], want)
+		}
+	}
+	if logger.contains("trying to close resource") {
+		t.Errorf("progress lines logged in summary mode:\n%s", logger)
+	}
+	if !logger.contains("ERROR error on close resource: db: boom") {
+		t.Errorf("errors not logged as they happen:\n%s", logger)
+	}
+}
+
+func TestSummaryThroughLogger(t *testing.T) {
+	logger := &testLogger{}
+	shutdown(t, NewManager(logger, WithSummary(nil)), &fakeResource{name: "db"})
+	if !logger.contains("INFO total: 1 calls, 0 failed, 0 timed out") {
+		t.Errorf("summary not logged:\n%s", logger)
+	}
+}
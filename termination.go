@@ -0,0 +1,77 @@
+package gracefulshutdown
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// terminationMessageLimit is the maximum size Kubernetes reads from a
+// termination message file.
+const terminationMessageLimit = 4096
+
+// WithTerminationMessage writes a concise summary of the shutdown (reason,
+// exit code and failed resources) to path when the sequence completes, such
+// as /dev/termination-log, so that it shows up in kubectl describe. The
+// message is truncated to the Kubernetes limit of 4096 bytes, keeping close
+// failures before failures of earlier phases and errors before timeouts.
+func WithTerminationMessage(path string) Option {
+	return func(m *Manager) {
+		m.terminationPath = path
+	}
+}
+
+func (m *Manager) writeTerminationMessage(report Report) {
+	if m.terminationPath == "" {
+		return
+	}
+	if err := os.WriteFile(m.terminationPath, []byte(terminationMessage(report)), 0o644); err != nil {
+		m.logger.Error(fmt.Sprintf("error on write termination message: %s", err.Error()))
+	}
+}
+
+func terminationMessage(report Report) string {
+	var failed []ResourceReport
+	for _, res := range report.Resources {
+		if res.Error != "" {
+			failed = append(failed, res)
+		}
+	}
+	sort.SliceStable(failed, func(i, j int) bool {
+		return failureRank(failed[i]) < failureRank(failed[j])
+	})
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "reason: %s\nexit code: %d\nfailed resources: %d\n", report.Reason, report.ExitCode, len(failed))
+	for i, res := range failed {
+		line := fmt.Sprintf("%s (%s): %s\n", res.Name, res.Phase, res.Error)
+		reserve := 0
+		if i < len(failed)-1 {
+			reserve = len(fmt.Sprintf("... %d more\n", len(failed)-i-1))
+		}
+		if b.Len()+len(line)+reserve > terminationMessageLimit {
+			fmt.Fprintf(&b, "... %d more\n", len(failed)-i)
+			break
+		}
+		b.WriteString(line)
+	}
+	s := b.String()
+	if len(s) > terminationMessageLimit {
+		s = s[:terminationMessageLimit]
+	}
+	return s
+}
+
+// failureRank orders failures by importance: errors before timeouts, and
+// close failures before failures of other phases.
+func failureRank(res ResourceReport) int {
+	rank := 0
+	if res.TimedOut {
+		rank += 2
+	}
+	if res.Phase != PhaseClose {
+		rank++
+	}
+	return rank
+}
@@ -0,0 +1,70 @@
+package gracefulshutdown
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestTerminationMessageFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "termination-log")
+	shutdown(t, NewManager(&testLogger{}, WithTerminationMessage(path)), &fakeResource{name: "cache"}, failing("db"))
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := fmt.Sprintf("reason: test\nexit code: %d\nfailed resources: 1\ndb (close): db: boom\n", ExitFailed)
+	if string(b) != want {
+		t.Errorf("message = %q, want %q", b, want)
+	}
+}
+
+func TestTerminationMessageOrdersFailures(t *testing.T) {
+	report := Report{Reason: "test", ExitCode: ExitFailed, Resources: []ResourceReport{
+		{Name: "drain-timeout", Phase: PhaseDrain, Error: "deadline", TimedOut: true},
+		{Name: "close-timeout", Phase: PhaseClose, Error: "deadline", TimedOut: true},
+		{Name: "drain-error", Phase: PhaseDrain, Error: "boom"},
+		{Name: "ok", Phase: PhaseClose},
+		{Name: "close-error", Phase: PhaseClose, Error: "boom"},
+	}}
+	lines := strings.Split(strings.TrimSuffix(terminationMessage(report), "\n"), "\n")
+	var names []string
+	for _, line := range lines[3:] {
+		names = append(names, line[:strings.Index(line, " ")])
+	}
+	if got := strings.Join(names, ","); got != "close-error,drain-error,close-timeout,drain-timeout" {
+		t.Errorf("order = %s", got)
+	}
+	if lines[1] != "exit code: 1" || lines[2] != "failed resources: 4" {
+		t.Errorf("header = %q", lines[:3])
+	}
+}
+
+func TestTerminationMessageTruncated(t *testing.T) {
+	report := Report{Reason: "test", ExitCode: ExitFailed}
+	for i := range 200 {
+		report.Resources = append(report.Resources, ResourceReport{
+			Name: fmt.Sprintf("resource-%03d", i), Phase: PhaseClose, Error: strings.Repeat("x", 40),
+		})
+	}
+	msg := terminationMessage(report)
+	if len(msg) > terminationMessageLimit {
+		t.Fatalf("message is %d bytes", len(msg))
+	}
+	lines := strings.Split(strings.TrimSuffix(msg, "\n"), "\n")
+	shown := len(lines) - 4
+	if want := fmt.Sprintf("... %d more", 200-shown); lines[len(lines)-1] != want {
+		t.Errorf("last line = %q, want %q", lines[len(lines)-1], want)
+	}
+	if !strings.HasPrefix(lines[3], "resource-000 ") {
+		t.Errorf("first failure = %q", lines[3])
+	}
+
+	report.Reason = strings.Repeat("r", 2*terminationMessageLimit)
+	if n := len(terminationMessage(report)); n != terminationMessageLimit {
+		t.Errorf("message with a long reason is %d bytes", n)
+	}
+}
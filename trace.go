@@ -0,0 +1,102 @@
+package gracefulshutdown
+
+import (
+	"fmt"
+	"os"
+	"runtime/trace"
+	"sync"
+	"time"
+)
+
+type traceConfig struct {
+	dir       string
+	threshold time.Duration
+	maxBytes  int64
+}
+
+// WithExecutionTrace records a runtime/trace execution trace into dir from the
+// moment the signal arrives until the shutdown sequence completes. The trace is
+// kept only if the sequence took longer than threshold or failed, and its path
+// is set on Report.TraceFile. Output beyond maxBytes is discarded, so a capped
+// trace may be truncated; a maxBytes of zero or less means no limit.
+func WithExecutionTrace(dir string, threshold time.Duration, maxBytes int64) Option {
+	return func(m *Manager) {
+		m.trace = &traceConfig{dir: dir, threshold: threshold, maxBytes: maxBytes}
+	}
+}
+
+type traceRecorder struct {
+	cfg  *traceConfig
+	file *os.File
+	w    *cappedWriter
+}
+
+// startTrace returns a nil recorder if tracing is not configured or could not
+// be started; stop is safe to call on it.
+func (m *Manager) startTrace() *traceRecorder {
+	if m.trace == nil {
+		return nil
+	}
+	if trace.IsEnabled() {
+		m.logger.Warn("execution trace skipped: another trace is already running")
+		return nil
+	}
+	f, err := os.CreateTemp(m.trace.dir, fmt.Sprintf("shutdown-%d-*.trace", os.Getpid()))
+	if err != nil {
+		m.logger.Error(fmt.Sprintf("error on create trace file: %s", err.Error()))
+		return nil
+	}
+	remaining := m.trace.maxBytes
+	if remaining <= 0 {
+		remaining = -1
+	}
+	w := &cappedWriter{file: f, remaining: remaining}
+	if err := trace.Start(w); err != nil {
+		m.logger.Error(fmt.Sprintf("error on start execution trace: %s", err.Error()))
+		f.Close()
+		os.Remove(f.Name())
+		return nil
+	}
+	return &traceRecorder{cfg: m.trace, file: f, w: w}
+}
+
+func (t *traceRecorder) stop(logger Logger, report *Report) {
+	if t == nil {
+		return
+	}
+	trace.Stop()
+	t.file.Close()
+	if report.Duration < t.cfg.threshold && !report.Failed() {
+		os.Remove(t.file.Name())
+		return
+	}
+	report.TraceFile = t.file.Name()
+	logger.Warn(fmt.Sprintf("execution trace written to %s", report.TraceFile))
+}
+
+// cappedWriter writes to file until remaining bytes have been written; a
+// negative remaining means no limit.
+type cappedWriter struct {
+	mu        sync.Mutex
+	file      *os.File
+	remaining int64
+}
+
+func (w *cappedWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.remaining == 0 {
+		return len(p), nil
+	}
+	n := int64(len(p))
+	if w.remaining > 0 && n > w.remaining {
+		n = w.remaining
+	}
+	if _, err := w.file.Write(p[:n]); err != nil {
+		return 0, err
+	}
+	if w.remaining > 0 {
+		w.remaining -= n
+	}
+	return len(p), nil
+}
@@ -0,0 +1,54 @@
+package gracefulshutdown
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func TestExecutionTraceKeptOnFailure(t *testing.T) {
+	dir := t.TempDir()
+	m := NewManager(&testLogger{}, WithExecutionTrace(dir, time.Hour, 0))
+	report := shutdown(t, m, failing("db"))
+	if report.TraceFile == "" {
+		t.Fatal("trace file not reported")
+	}
+	info, err := os.Stat(report.TraceFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Size() == 0 {
+		t.Error("unlimited trace is empty")
+	}
+}
+
+func TestExecutionTraceKeptWhenSlow(t *testing.T) {
+	m := NewManager(&testLogger{}, WithExecutionTrace(t.TempDir(), time.Nanosecond, 1<<20))
+	if report := shutdown(t, m, &fakeResource{name: "db"}); report.TraceFile == "" {
+		t.Error("trace of a slow shutdown not kept")
+	}
+}
+
+func TestExecutionTraceRemovedWhenFastAndClean(t *testing.T) {
+	dir := t.TempDir()
+	m := NewManager(&testLogger{}, WithExecutionTrace(dir, time.Hour, 1<<20))
+	if report := shutdown(t, m, &fakeResource{name: "db"}); report.TraceFile != "" {
+		t.Errorf("trace file kept: %s", report.TraceFile)
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 0 {
+		t.Errorf("trace file not removed: %v", entries)
+	}
+}
+
+func TestExecutionTraceCapped(t *testing.T) {
+	m := NewManager(&testLogger{}, WithExecutionTrace(t.TempDir(), 0, 64))
+	report := shutdown(t, m, failing("db"))
+	info, err := os.Stat(report.TraceFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Size() != 64 {
+		t.Errorf("trace size = %d, want 64", info.Size())
+	}
+}
@@ -0,0 +1,193 @@
+package gracefulshutdown
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Transport is an http.RoundTripper that tracks in-flight outbound requests.
+// Handled by a Manager, it waits for them in the drain phase and cancels the
+// ones still running when the drain deadline passes. A request is in flight
+// until its response body is read to the end or closed. The body of a 101
+// Switching Protocols response keeps its io.Writer side, and the upgraded
+// connection is closed when the request is cancelled.
+type Transport struct {
+	base http.RoundTripper
+
+	mu        sync.Mutex
+	inflight  map[*outboundRequest]struct{}
+	idle      chan struct{}
+	cancelled map[string]int
+}
+
+type outboundRequest struct {
+	host   string
+	cancel context.CancelFunc
+	// conn is the upgraded connection of a 101 response and cancelled is set
+	// once the request was cancelled at shutdown, both guarded by the
+	// Transport lock.
+	conn      io.Closer
+	cancelled bool
+}
+
+// NewTransport wraps base, or http.DefaultTransport if base is nil.
+func NewTransport(base http.RoundTripper) *Transport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &Transport{base: base, inflight: map[*outboundRequest]struct{}{}, cancelled: map[string]int{}}
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx, cancel := context.WithCancel(req.Context())
+	r := &outboundRequest{host: req.URL.Host, cancel: cancel}
+	t.mu.Lock()
+	t.inflight[r] = struct{}{}
+	t.mu.Unlock()
+
+	resp, err := t.base.RoundTrip(req.WithContext(ctx))
+	if err != nil {
+		t.done(r)
+		return nil, err
+	}
+	body := &trackedBody{ReadCloser: resp.Body, done: func() { t.done(r) }}
+	if rwc, ok := resp.Body.(io.ReadWriteCloser); ok && resp.StatusCode == http.StatusSwitchingProtocols {
+		t.mu.Lock()
+		r.conn = rwc
+		t.mu.Unlock()
+		resp.Body = &trackedConn{trackedBody: body, w: rwc}
+		return resp, nil
+	}
+	resp.Body = body
+	return resp, nil
+}
+
+func (t *Transport) done(r *outboundRequest) {
+	r.cancel()
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.inflight, r)
+	if len(t.inflight) == 0 && t.idle != nil {
+		close(t.idle)
+		t.idle = nil
+	}
+}
+
+// InFlight returns the number of outbound requests in flight.
+func (t *Transport) InFlight() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return len(t.inflight)
+}
+
+// Drain waits for the in-flight requests to finish. When ctx is done first,
+// the remaining requests are cancelled and reported by host.
+func (t *Transport) Drain(ctx context.Context) error {
+	t.mu.Lock()
+	if len(t.inflight) == 0 {
+		t.mu.Unlock()
+		return nil
+	}
+	if t.idle == nil {
+		t.idle = make(chan struct{})
+	}
+	idle := t.idle
+	t.mu.Unlock()
+
+	select {
+	case <-idle:
+		return nil
+	case <-ctx.Done():
+	}
+	byHost := t.cancelAll()
+	if len(byHost) == 0 {
+		return nil
+	}
+	hosts := make([]string, 0, len(byHost))
+	for host, n := range byHost {
+		hosts = append(hosts, fmt.Sprintf("%s=%d", host, n))
+	}
+	sort.Strings(hosts)
+	return fmt.Errorf("cancelled outbound requests (%s): %w", strings.Join(hosts, ", "), ctx.Err())
+}
+
+// Cancelled returns the number of outbound requests cancelled at shutdown by
+// host.
+func (t *Transport) Cancelled() map[string]int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	out := make(map[string]int, len(t.cancelled))
+	for host, n := range t.cancelled {
+		out[host] = n
+	}
+	return out
+}
+
+// Close cancels any request still in flight and closes the idle connections of
+// the wrapped transport.
+func (t *Transport) Close() error {
+	t.cancelAll()
+	if c, ok := t.base.(interface{ CloseIdleConnections() }); ok {
+		c.CloseIdleConnections()
+	}
+	return nil
+}
+
+func (t *Transport) Name() string {
+	return "http transport"
+}
+
+func (t *Transport) cancelAll() map[string]int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	byHost := map[string]int{}
+	for r := range t.inflight {
+		if r.cancelled {
+			continue
+		}
+		r.cancelled = true
+		r.cancel()
+		if r.conn != nil {
+			r.conn.Close()
+		}
+		byHost[r.host]++
+		t.cancelled[r.host]++
+	}
+	return byHost
+}
+
+type trackedBody struct {
+	io.ReadCloser
+	once sync.Once
+	done func()
+}
+
+func (b *trackedBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if err == io.EOF {
+		b.once.Do(b.done)
+	}
+	return n, err
+}
+
+func (b *trackedBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(b.done)
+	return err
+}
+
+// trackedConn is the body of a 101 response, which is also the write side of
+// the upgraded connection.
+type trackedConn struct {
+	*trackedBody
+	w io.Writer
+}
+
+func (c *trackedConn) Write(p []byte) (int, error) {
+	return c.w.Write(p)
+}
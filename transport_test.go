@@ -0,0 +1,149 @@
+package gracefulshutdown
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTransportTracksUntilBodyClosed(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "ok")
+	}))
+	defer srv.Close()
+	transport := NewTransport(nil)
+	client := &http.Client{Transport: transport}
+
+	resp, err := client.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := transport.InFlight(); n != 1 {
+		t.Errorf("in flight before reading body = %d", n)
+	}
+	io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if n := transport.InFlight(); n != 0 {
+		t.Errorf("in flight after closing body = %d", n)
+	}
+	if err := transport.Drain(context.Background()); err != nil {
+		t.Errorf("drain with nothing in flight = %v", err)
+	}
+}
+
+func TestTransportDrainCancelsStragglers(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Send the headers and hold the body open.
+		w.WriteHeader(http.StatusOK)
+		w.(http.Flusher).Flush()
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer srv.Close()
+	defer close(release)
+	transport := NewTransport(nil)
+	m := NewManager(&testLogger{}, WithDrainTimeout(20*time.Millisecond))
+
+	resp, err := (&http.Client{Transport: transport}).Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	read := make(chan error, 1)
+	go func() {
+		_, err := io.ReadAll(resp.Body)
+		read <- err
+	}()
+	report := shutdown(t, m, transport)
+
+	host := strings.TrimPrefix(srv.URL, "http://")
+	if len(report.Resources) != 2 {
+		t.Fatalf("report = %+v", report.Resources)
+	}
+	if drain := report.Resources[0]; !strings.Contains(drain.Error, host+"=1") || !drain.TimedOut {
+		t.Errorf("drain = %+v", drain)
+	}
+	if err := <-read; !errors.Is(err, context.Canceled) {
+		t.Errorf("cancelled request = %v", err)
+	}
+	// The request stays in flight until its body is closed, but the close
+	// phase does not cancel it again.
+	if n := transport.Cancelled()[host]; n != 1 {
+		t.Errorf("cancelled for %s = %d", host, n)
+	}
+	resp.Body.Close()
+	if n := transport.InFlight(); n != 0 {
+		t.Errorf("in flight after closing body = %d", n)
+	}
+}
+
+// upgradeServer answers with 101 Switching Protocols and echoes every line
+// received on the upgraded connection.
+func upgradeServer(t *testing.T) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, rw, err := http.NewResponseController(w).Hijack()
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer conn.Close()
+		rw.WriteString("HTTP/1.1 101 Switching Protocols\r\nConnection: Upgrade\r\nUpgrade: echo\r\n\r\n")
+		rw.Flush()
+		for {
+			line, err := rw.ReadString('\n')
+			if err != nil {
+				return
+			}
+			rw.WriteString(line)
+			rw.Flush()
+		}
+	}))
+}
+
+func TestTransportKeepsUpgradedConnectionWritable(t *testing.T) {
+	srv := upgradeServer(t)
+	defer srv.Close()
+	transport := NewTransport(nil)
+
+	req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", "echo")
+	resp, err := (&http.Client{Transport: transport}).Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		t.Fatalf("status = %d", resp.StatusCode)
+	}
+	conn, ok := resp.Body.(io.ReadWriteCloser)
+	if !ok {
+		t.Fatalf("upgraded body %T is not writable", resp.Body)
+	}
+	if _, err := io.WriteString(conn, "ping\n"); err != nil {
+		t.Fatal(err)
+	}
+	r := bufio.NewReader(conn)
+	if line, err := r.ReadString('\n'); err != nil || line != "ping\n" {
+		t.Fatalf("echo = %q, %v", line, err)
+	}
+	if n := transport.InFlight(); n != 1 {
+		t.Errorf("in flight = %d", n)
+	}
+
+	transport.Close()
+	if _, err := r.ReadString('\n'); err == nil {
+		t.Error("upgraded connection still open after Close")
+	}
+	conn.Close()
+	if n := transport.InFlight(); n != 0 {
+		t.Errorf("in flight after closing body = %d", n)
+	}
+}
@@ -0,0 +1,127 @@
+package gracefulshutdown
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ReadDeadliner is implemented by handles such as net.Conn and *os.File whose
+// blocking reads return once their deadline passes. Handles that also have a
+// SetDeadline method get their write deadline set too.
+type ReadDeadliner interface {
+	SetReadDeadline(t time.Time) error
+}
+
+type unblockRegistry struct {
+	mu        sync.Mutex
+	phase     Phase
+	handles   map[*ReadDeadliner]struct{}
+	done      bool
+	unblocked map[string]int
+}
+
+var phaseOrder = map[Phase]int{
+	PhaseReadiness: 0,
+	PhasePause:     1,
+	PhaseDrain:     2,
+	PhaseClose:     3,
+	PhaseZeroize:   4,
+}
+
+// WithUnblockPhase sets the phase at whose start the deadlines of handles
+// registered with Unblock are set to now. Defaults to PhaseDrain.
+func WithUnblockPhase(phase Phase) Option {
+	return func(m *Manager) {
+		m.unblock.phase = phase
+	}
+}
+
+// Unblock registers h so that goroutines blocked reading from it wake up when
+// the shutdown sequence reaches the unblock phase. A handle registered after
+// that has its deadline set at once. Call the returned function once h is no
+// longer in use. The drain of maintenance mode does not unblock handles, since
+// the instance may resume.
+func (m *Manager) Unblock(h ReadDeadliner) (release func()) {
+	key := &h
+	m.unblock.mu.Lock()
+	defer m.unblock.mu.Unlock()
+	if m.unblock.done {
+		m.unblock.expire(h, time.Now(), m.logger)
+	}
+	if m.unblock.handles == nil {
+		m.unblock.handles = map[*ReadDeadliner]struct{}{}
+	}
+	m.unblock.handles[key] = struct{}{}
+	return func() {
+		m.unblock.mu.Lock()
+		defer m.unblock.mu.Unlock()
+		delete(m.unblock.handles, key)
+	}
+}
+
+// unblockAt sets the deadline of every registered handle to now the first
+// time the sequence reaches phase or a later one.
+func (m *Manager) unblockAt(phase Phase) {
+	r := &m.unblock
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	target := r.phase
+	if target == "" {
+		target = PhaseDrain
+	}
+	if r.done || phaseOrder[phase] < phaseOrder[target] {
+		return
+	}
+	r.done = true
+	if len(r.handles) == 0 {
+		return
+	}
+	now := time.Now()
+	for key := range r.handles {
+		r.expire(*key, now, m.logger)
+	}
+	counts := make([]string, 0, len(r.unblocked))
+	for typ, n := range r.unblocked {
+		counts = append(counts, fmt.Sprintf("%s=%d", typ, n))
+	}
+	sort.Strings(counts)
+	m.logger.Info(fmt.Sprintf("unblocked handles: %s", strings.Join(counts, ", ")))
+}
+
+// expire sets the deadline of h to now and counts it by type. The caller holds
+// r.mu.
+func (r *unblockRegistry) expire(h ReadDeadliner, now time.Time, logger Logger) {
+	var err error
+	if d, ok := h.(interface{ SetDeadline(time.Time) error }); ok {
+		err = d.SetDeadline(now)
+	} else {
+		err = h.SetReadDeadline(now)
+	}
+	if err != nil {
+		logger.Warn(fmt.Sprintf("error on unblock %T: %s", h, err.Error()))
+		return
+	}
+	if r.unblocked == nil {
+		r.unblocked = map[string]int{}
+	}
+	r.unblocked[fmt.Sprintf("%T", h)]++
+}
+
+// unblocked returns the number of handles unblocked so far by type, or nil if
+// there were none.
+func (m *Manager) unblocked() map[string]int {
+	r := &m.unblock
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.unblocked) == 0 {
+		return nil
+	}
+	out := make(map[string]int, len(r.unblocked))
+	for typ, n := range r.unblocked {
+		out[typ] = n
+	}
+	return out
+}
@@ -0,0 +1,99 @@
+package gracefulshutdown
+
+import (
+	"context"
+	"errors"
+	"net"
+	"os"
+	"testing"
+	"time"
+)
+
+// blockedRead starts a read on conn and returns a channel receiving its error.
+func blockedRead(conn net.Conn) <-chan error {
+	read := make(chan error, 1)
+	go func() {
+		_, err := conn.Read(make([]byte, 1))
+		read <- err
+	}()
+	return read
+}
+
+func TestUnblockWakesBlockedReads(t *testing.T) {
+	m := NewManager(&testLogger{})
+	client, server := net.Pipe()
+	defer client.Close()
+	defer server.Close()
+	_, released := net.Pipe()
+	defer released.Close()
+
+	m.Unblock(server)
+	m.Unblock(released)()
+	read := blockedRead(server)
+	report := shutdown(t, m)
+
+	select {
+	case err := <-read:
+		if !errors.Is(err, os.ErrDeadlineExceeded) {
+			t.Errorf("read = %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("read still blocked after shutdown")
+	}
+	if n := report.Unblocked["*net.pipe"]; n != 1 || len(report.Unblocked) != 1 {
+		t.Errorf("unblocked = %v", report.Unblocked)
+	}
+}
+
+func TestUnblockAfterUnblockPhase(t *testing.T) {
+	m := NewManager(&testLogger{}, WithUnblockPhase(PhasePause))
+	client, server := net.Pipe()
+	defer client.Close()
+	defer server.Close()
+
+	// The resource registers a connection once handles were already
+	// unblocked, as a server accepting during the drain would.
+	res := &drainingResource{fakeResource: fakeResource{name: "server"}}
+	res.drain = func(ctx context.Context) error {
+		m.Unblock(server)
+		return nil
+	}
+	report := shutdown(t, m, res)
+
+	select {
+	case err := <-blockedRead(server):
+		if !errors.Is(err, os.ErrDeadlineExceeded) {
+			t.Errorf("read = %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("late handle not unblocked")
+	}
+	if n := report.Unblocked["*net.pipe"]; n != 1 {
+		t.Errorf("unblocked = %v", report.Unblocked)
+	}
+}
+
+func TestMaintenanceDoesNotUnblock(t *testing.T) {
+	m := NewManager(&testLogger{})
+	client, server := net.Pipe()
+	defer client.Close()
+	defer server.Close()
+	m.Unblock(server)
+	done := handle(m)
+	waitHandling(t, m)
+	if err := m.EnterMaintenance(); err != nil {
+		t.Fatal(err)
+	}
+
+	read := blockedRead(server)
+	select {
+	case err := <-read:
+		t.Errorf("read returned during maintenance: %v", err)
+	case <-time.After(20 * time.Millisecond):
+	}
+	m.Trigger("test")
+	<-done
+	if err := <-read; !errors.Is(err, os.ErrDeadlineExceeded) {
+		t.Errorf("read after shutdown = %v", err)
+	}
+}
@@ -0,0 +1,89 @@
+package gracefulshutdown
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+)
+
+// ContextCloseable is implemented by resources whose close honours a context.
+// The manager prefers CloseContext over Close and passes a context carrying
+// its Logger, available through LoggerFromContext.
+type ContextCloseable interface {
+	Closeable
+	CloseContext(ctx context.Context) error
+}
+
+func closeContext(ctx context.Context, c Closeable) error {
+	if cc, ok := c.(ContextCloseable); ok {
+		return cc.CloseContext(ctx)
+	}
+	return c.Close()
+}
+
+// DebugLogger is a Logger that also logs at debug level, such as *slog.Logger.
+type DebugLogger interface {
+	Logger
+	Debug(string, ...any)
+}
+
+// LevelSetter is implemented by loggers whose level can be changed at runtime.
+type LevelSetter interface {
+	SetLevel(slog.Level)
+}
+
+type loggerKey struct{}
+
+// WithShutdownLevel raises log verbosity to level when the shutdown sequence
+// starts, by setting v if it is not nil and by calling SetLevel if the Logger
+// implements LevelSetter.
+func WithShutdownLevel(level slog.Level, v *slog.LevelVar) Option {
+	return func(m *Manager) {
+		m.shutdownLevel = &level
+		m.levelVar = v
+	}
+}
+
+func (m *Manager) raiseVerbosity() {
+	if m.shutdownLevel == nil {
+		return
+	}
+	level := *m.shutdownLevel
+	if m.levelVar != nil {
+		m.levelVar.Set(level)
+	}
+	if s, ok := m.logger.(LevelSetter); ok {
+		s.SetLevel(level)
+	}
+	m.logger.Info(fmt.Sprintf("log level set to %s", level))
+}
+
+// LoggerFromContext returns the logger passed by the manager to Drainable and
+// ContextCloseable resources. If the manager Logger has no Debug method, debug
+// messages are logged at info level; without a manager logger in ctx, messages
+// are discarded.
+func LoggerFromContext(ctx context.Context) DebugLogger {
+	l, ok := ctx.Value(loggerKey{}).(Logger)
+	if !ok {
+		return nopLogger{}
+	}
+	if d, ok := l.(DebugLogger); ok {
+		return d
+	}
+	return infoDebugLogger{l}
+}
+
+type infoDebugLogger struct {
+	Logger
+}
+
+func (l infoDebugLogger) Debug(msg string, args ...any) {
+	l.Info(msg, args...)
+}
+
+type nopLogger struct{}
+
+func (nopLogger) Debug(string, ...any) {}
+func (nopLogger) Info(string, ...any)  {}
+func (nopLogger) Warn(string, ...any)  {}
+func (nopLogger) Error(string, ...any) {}
@@ -0,0 +1,85 @@
+package gracefulshutdown
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+// levelLogger is a testLogger whose level can be raised at runtime.
+type levelLogger struct {
+	testLogger
+	level slog.Level
+}
+
+func (l *levelLogger) SetLevel(level slog.Level) { l.level = level }
+
+func TestShutdownLevel(t *testing.T) {
+	var v slog.LevelVar
+	logger := &levelLogger{level: slog.LevelInfo}
+	shutdown(t, NewManager(logger, WithShutdownLevel(slog.LevelDebug, &v)))
+
+	if v.Level() != slog.LevelDebug || logger.level != slog.LevelDebug {
+		t.Errorf("levels = %v, %v", v.Level(), logger.level)
+	}
+	if !logger.contains("INFO log level set to DEBUG") {
+		t.Errorf("level change not logged:\n%s", logger)
+	}
+}
+
+// contextResource records what its CloseContext received.
+type contextResource struct {
+	fakeResource
+	deadline bool
+	logger   DebugLogger
+}
+
+func (r *contextResource) CloseContext(ctx context.Context) error {
+	r.record("close context")
+	_, r.deadline = ctx.Deadline()
+	r.logger = LoggerFromContext(ctx)
+	r.logger.Debug("closing")
+	return nil
+}
+
+func TestContextCloseableReceivesLoggerAndDeadline(t *testing.T) {
+	logger := &testLogger{}
+	m := NewManager(logger)
+	res := &contextResource{fakeResource: fakeResource{name: "db"}}
+	tracked := m.Track("db", res)
+	m.trigger(shutdownTrigger{reason: "test", deadline: time.Now().Add(time.Minute)})
+	<-handle(m, CloseOnce(tracked))
+
+	if got := res.called(); len(got) != 1 || got[0] != "close context" {
+		t.Fatalf("calls = %v", got)
+	}
+	if !res.deadline {
+		t.Error("deadline lost through CloseOnce and Track")
+	}
+	if !logger.contains("DEBUG closing") {
+		t.Errorf("debug message not logged through the manager logger:\n%s", logger)
+	}
+}
+
+// infoLogger has no Debug method.
+type infoLogger struct{ l *testLogger }
+
+func (l infoLogger) Info(msg string, args ...any)  { l.l.Info(msg, args...) }
+func (l infoLogger) Warn(msg string, args ...any)  { l.l.Warn(msg, args...) }
+func (l infoLogger) Error(msg string, args ...any) { l.l.Error(msg, args...) }
+
+func TestLoggerFromContext(t *testing.T) {
+	LoggerFromContext(context.Background()).Error("discarded")
+
+	logger := &testLogger{}
+	ctx := context.WithValue(context.Background(), loggerKey{}, Logger(infoLogger{logger}))
+	LoggerFromContext(ctx).Debug("detail")
+	if !logger.contains("INFO detail") {
+		t.Errorf("debug not logged at info level:\n%s", logger)
+	}
+	if err := closeContext(ctx, failing("db")); !errors.Is(err, errBoom) {
+		t.Errorf("closeContext = %v", err)
+	}
+}
@@ -0,0 +1,104 @@
+package gracefulshutdown
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+)
+
+// Zeroizer wipes sensitive material held by a resource. Zeroize runs in the
+// final phase, after every resource has been closed, and also when the
+// sequence is interrupted by a panic in an earlier phase.
+type Zeroizer interface {
+	Zeroize() error
+}
+
+// Secret holds key material that is overwritten with zeros in the zeroize
+// phase.
+type Secret []byte
+
+func (s Secret) Zeroize() error {
+	clear(s)
+	return nil
+}
+
+// SecretFile is a sensitive file that is overwritten and removed in the
+// zeroize phase.
+type SecretFile string
+
+func (f SecretFile) Zeroize() error {
+	return RemoveSecureFile(string(f))
+}
+
+// WithZeroizers registers secrets that are not resources themselves, such as
+// Secret and SecretFile values, for the zeroize phase.
+func WithZeroizers(z ...Zeroizer) Option {
+	return func(m *Manager) {
+		m.zeroizers = append(m.zeroizers, z...)
+	}
+}
+
+// RemoveSecureFile overwrites the content of the file at path with zeros,
+// flushes it to stable storage and removes it. A missing file is not an error.
+// Symbolic links and other non-regular files are refused, so a link planted
+// in place of a secret cannot redirect the overwrite to another file.
+func RemoveSecureFile(path string) error {
+	info, err := os.Lstat(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if !info.Mode().IsRegular() {
+		return fmt.Errorf("gracefulshutdown: remove secure file %s: not a regular file", path)
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|oNoFollow, 0)
+	if err != nil {
+		return err
+	}
+	opened, err := f.Stat()
+	if err == nil && !os.SameFile(info, opened) {
+		err = fmt.Errorf("gracefulshutdown: remove secure file %s: replaced while opening", path)
+	}
+	if err == nil {
+		_, err = io.CopyN(f, zeroReader{}, opened.Size())
+	}
+	if err == nil {
+		err = f.Sync()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+	return os.Remove(path)
+}
+
+type zeroReader struct{}
+
+func (zeroReader) Read(p []byte) (int, error) {
+	clear(p)
+	return len(p), nil
+}
+
+func (m *Manager) zeroize(ctx context.Context, resources []handled) []ResourceReport {
+	var targets []phaseTarget
+	for _, h := range resources {
+		if z, ok := as[Zeroizer](h.resource); ok {
+			targets = append(targets, phaseTarget{index: h.index, resource: h.resource, call: zeroizeCall(z)})
+		}
+	}
+	for i, z := range m.zeroizers {
+		targets = append(targets, phaseTarget{index: len(resources) + i, resource: z, call: zeroizeCall(z)})
+	}
+	return m.runTargets(ctx, PhaseZeroize, targets)
+}
+
+func zeroizeCall(z Zeroizer) func(context.Context) error {
+	return func(context.Context) error { return z.Zeroize() }
+}
@@ -0,0 +1,7 @@
+//go:build !unix
+
+package gracefulshutdown
+
+// oNoFollow is not available; RemoveSecureFile relies on its Lstat and
+// SameFile checks.
+const oNoFollow = 0
@@ -0,0 +1,59 @@
+package gracefulshutdown
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSecretZeroize(t *testing.T) {
+	key := Secret("private key")
+	shutdown(t, NewManager(&testLogger{}, WithZeroizers(key)), &fakeResource{name: "db"})
+	if !bytes.Equal(key, make([]byte, len(key))) {
+		t.Errorf("secret not zeroized: %q", key)
+	}
+}
+
+func TestRemoveSecureFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(path, []byte("secret token"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := RemoveSecureFile(path); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Lstat(path); !os.IsNotExist(err) {
+		t.Errorf("file not removed: %v", err)
+	}
+}
+
+func TestRemoveSecureFileMissing(t *testing.T) {
+	if err := RemoveSecureFile(filepath.Join(t.TempDir(), "missing")); err != nil {
+		t.Errorf("missing file = %v", err)
+	}
+}
+
+func TestRemoveSecureFileRefusesSymlink(t *testing.T) {
+	dir := t.TempDir()
+	target := filepath.Join(dir, "passwd")
+	link := filepath.Join(dir, "token")
+	if err := os.WriteFile(target, []byte("keep me"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(target, link); err != nil {
+		t.Skipf("symlinks not supported: %v", err)
+	}
+	if err := RemoveSecureFile(link); err == nil {
+		t.Error("symlink removed without error")
+	}
+	if b, _ := os.ReadFile(target); string(b) != "keep me" {
+		t.Errorf("link target overwritten: %q", b)
+	}
+}
+
+func TestRemoveSecureFileRefusesDirectory(t *testing.T) {
+	if err := RemoveSecureFile(t.TempDir()); err == nil {
+		t.Error("directory removed without error")
+	}
+}
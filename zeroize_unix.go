@@ -0,0 +1,9 @@
+//go:build unix
+
+package gracefulshutdown
+
+import "syscall"
+
+// oNoFollow makes RemoveSecureFile fail if path is swapped for a symbolic
+// link between the Lstat check and the open.
+const oNoFollow = syscall.O_NOFOLLOW